@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	"win_helper/cmd/win_helper/sub"
 )
@@ -10,5 +11,7 @@ func main() {
 	err := sub.Execute()
 	if err != nil {
 		fmt.Printf("Error executing: %v\n", err)
+		// 校验失败等错误返回非零退出码，便于在 CI 中使用
+		os.Exit(1)
 	}
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/flosch/pongo2/v6"
 	"github.com/spf13/cobra"
 
+	"win_helper/pkg/obr"
 	"win_helper/pkg/project"
 )
 
@@ -30,9 +31,20 @@ type InitReadmeConfig struct {
 	OutFile       string
 }
 
+type InitISSConfig struct {
+	Manifest string
+	OutFile  string
+	Force    bool
+	Verbose  bool
+	Icons    []string
+	Run      []string
+	Project  obr.ISSProject
+}
+
 var (
 	initReadmeConfig  = InitReadmeConfig{}
 	initProjectConfig = InitProjectConfig{}
+	initISSConfig     = InitISSConfig{}
 )
 
 func init() {
@@ -52,6 +64,25 @@ func init() {
 	initReadmeCmd.Flags().BoolVarP(&initReadmeConfig.Force, "force", "f", false, "force write file (default: false)")
 	initReadmeCmd.Flags().BoolVar(&initReadmeConfig.Verbose, "verbose", false, "verbose")
 	_ = initReadmeCmd.MarkFlagRequired("name")
+
+	initCmd.AddCommand(initISSCmd)
+	initISSCmd.Flags().StringVarP(&initISSConfig.Manifest, "manifest", "m", "", "installer manifest (yaml or json), flags override its values")
+	initISSCmd.Flags().StringVarP(&initISSConfig.OutFile, "out", "o", "setup.iss", "output file")
+	initISSCmd.Flags().BoolVarP(&initISSConfig.Force, "force", "f", false, "overwrite existing file")
+	initISSCmd.Flags().BoolVar(&initISSConfig.Verbose, "verbose", false, "print script instead of writing it")
+	initISSCmd.Flags().StringVarP(&initISSConfig.Project.Name, "name", "n", "", "app name")
+	initISSCmd.Flags().StringVarP(&initISSConfig.Project.Version, "version", "v", "", "app version")
+	initISSCmd.Flags().StringVar(&initISSConfig.Project.Publisher, "publisher", "", "app publisher")
+	initISSCmd.Flags().StringVar(&initISSConfig.Project.URL, "url", "", "app url")
+	initISSCmd.Flags().StringVar(&initISSConfig.Project.AppId, "app-id", "", "AppId GUID (default: random)")
+	initISSCmd.Flags().StringVar(&initISSConfig.Project.OutputDir, "output-dir", "", "installer output dir (default: output)")
+	initISSCmd.Flags().StringVar(&initISSConfig.Project.OutputBaseFilename, "output-name", "", "installer base filename")
+	initISSCmd.Flags().StringVar(&initISSConfig.Project.DefaultDir, "default-dir", "", `default install dir (default: {autopf}\{#MyAppName})`)
+	initISSCmd.Flags().StringVarP(&initISSConfig.Project.Dist, "dist", "d", "", "directory to package")
+	initISSCmd.Flags().StringVar(&initISSConfig.Project.Exe, "exe", "", "main executable, relative to dist")
+	initISSCmd.Flags().StringArrayVar(&initISSConfig.Icons, "icon", []string{}, "start menu icon: name|filename[|parameters]")
+	initISSCmd.Flags().StringArrayVar(&initISSConfig.Run, "run", []string{}, "post-install run entry: filename[|parameters[|description]]")
+	initISSCmd.Flags().StringVar(&initISSConfig.Project.ServiceManifest, "services", "", "winserver manifest, services are installed with the app")
 }
 
 var initCmd = &cobra.Command{
@@ -171,6 +202,100 @@ var initReadmeCmd = &cobra.Command{
 	},
 }
 
+var initISSCmd = &cobra.Command{
+	Use:   "iss",
+	Short: "init Inno Setup script",
+	Long: `init Inno Setup script
+
+根据参数或清单文件生成完整的 Inno Setup 脚本, dist 目录中的文件全部写入 [Files],
+指定 --services 时服务文件会生成到 dist 中 (--verbose 时不生成), 安装前停止已安装的服务, 安装时注册并启动, 卸载时停止并删除。`,
+	Example: `  win_helper init iss -n demo -v 1.0.0 -d dist --exe demo.exe
+  win_helper init iss -m installer.yaml --services services.yaml`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := initISSProject(cmd)
+		if err != nil {
+			return err
+		}
+		filename := initISSConfig.OutFile
+		if !initISSConfig.Verbose && fsutil.FileExist(filename) && !initISSConfig.Force {
+			return fmt.Errorf("文件 %s 已经存在, 使用 --force 覆盖", filename)
+		}
+		out, err := p.Generate(filename)
+		if err != nil {
+			return err
+		}
+		// --verbose 只输出脚本，不生成服务文件
+		if initISSConfig.Verbose {
+			fmt.Println(string(out))
+			return nil
+		}
+		if err := p.GenerateServices(); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filename, out, 0o644); err != nil {
+			return fmt.Errorf("写入文件失败。%v", err)
+		}
+		fmt.Printf("generated %s\n", filename)
+		return nil
+	},
+}
+
+// initISSProject 读取清单并用显式设置的参数覆盖
+func initISSProject(cmd *cobra.Command) (*obr.ISSProject, error) {
+	p := &obr.ISSProject{}
+	if initISSConfig.Manifest != "" {
+		var err error
+		if p, err = obr.LoadISSProject(initISSConfig.Manifest); err != nil {
+			return nil, err
+		}
+	}
+	flags := initISSConfig.Project
+	overrides := map[string]func(){
+		"name":        func() { p.Name = flags.Name },
+		"version":     func() { p.Version = flags.Version },
+		"publisher":   func() { p.Publisher = flags.Publisher },
+		"url":         func() { p.URL = flags.URL },
+		"app-id":      func() { p.AppId = flags.AppId },
+		"output-dir":  func() { p.OutputDir = flags.OutputDir },
+		"output-name": func() { p.OutputBaseFilename = flags.OutputBaseFilename },
+		"default-dir": func() { p.DefaultDir = flags.DefaultDir },
+		"dist":        func() { p.Dist = flags.Dist },
+		"exe":         func() { p.Exe = flags.Exe },
+		"services":    func() { p.ServiceManifest = flags.ServiceManifest },
+	}
+	for name, override := range overrides {
+		if cmd.Flags().Changed(name) {
+			override()
+		}
+	}
+	for _, icon := range initISSConfig.Icons {
+		parts := strings.Split(icon, "|")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("invalid icon: %s", icon)
+		}
+		i := &obr.ISSIcon{Name: parts[0], Filename: parts[1]}
+		if len(parts) == 3 {
+			i.Parameters = parts[2]
+		}
+		p.Icons = append(p.Icons, i)
+	}
+	for _, run := range initISSConfig.Run {
+		parts := strings.Split(run, "|")
+		if len(parts) > 3 {
+			return nil, fmt.Errorf("invalid run: %s", run)
+		}
+		r := &obr.ISSRun{Filename: parts[0]}
+		if len(parts) > 1 {
+			r.Parameters = parts[1]
+		}
+		if len(parts) > 2 {
+			r.Description = parts[2]
+		}
+		p.Run = append(p.Run, r)
+	}
+	return p, nil
+}
+
 func ParseShieldString(shieldString []string) []Shield {
 	var shields []Shield
 	for _, shield := range shieldString {
@@ -5,27 +5,27 @@ import (
 	"github.com/spf13/cobra"
 	"os"
 	"path/filepath"
+	"time"
 	"win_helper/pkg/obr"
-	"win_helper/pkg/util/versionUtils/manager"
 )
 
 type OBRUpdateAppCmdConfig struct {
-	IssPath string
 	Version string
-
-	PushGit    bool
-	GitMessage string
 }
 
 type OBRUpdateISSCmdConfig struct {
-	IssPath string
 	Version string
+}
 
-	PushGit    bool
-	GitMessage string
+// OBRCmdConfig obr 命令的公共参数，其余设置见 obr.Config
+type OBRCmdConfig struct {
+	ConfigFile string
+	DryRun     bool
+	SkipCheck  bool
 }
 
 var (
+	obrCmdConfig          = &OBRCmdConfig{}
 	obrUpdateISSCmdConfig = &OBRUpdateISSCmdConfig{}
 	obrUpdateAppCmdConfig = &OBRUpdateAppCmdConfig{}
 )
@@ -35,23 +35,36 @@ func init() {
 	obrCmd.AddCommand(updateAppCmd)
 	obrCmd.AddCommand(updateISSCmd)
 
-	updateISSCmd.Flags().StringVarP(&obrUpdateISSCmdConfig.Version, "version", "v", "+", "git version message")
-	updateISSCmd.Flags().StringVarP(&obrUpdateISSCmdConfig.IssPath, "iss-path", "", "C:\\dist\\chemical_server.iss", "iss_path")
+	// 以下参数覆盖 .obr.yaml 中的同名配置，默认值见 obr.DefaultConfig
+	obrCmd.PersistentFlags().StringVarP(&obrCmdConfig.ConfigFile, "config", "c", obr.ConfigFile, "obr settings file")
+	obrCmd.PersistentFlags().BoolVar(&obrCmdConfig.DryRun, "dry-run", false, "print git commands and file changes without running them")
+	obrCmd.PersistentFlags().BoolVar(&obrCmdConfig.SkipCheck, "skip-check", false, "skip preflight checks (clean tree, branch, tag, version)")
+	obrCmd.PersistentFlags().StringSlice("branch", nil, "branches allowed to release, supports wildcards (default main,master)")
+	obrCmd.PersistentFlags().String("tag-template", "", "tag name template, e.g. v{{ version }}")
+	obrCmd.PersistentFlags().String("remote", "", "git remote (default origin)")
+	obrCmd.PersistentFlags().Bool("sign-tags", false, "create signed tags (git tag -s)")
 
-	updateISSCmd.Flags().BoolVar(&obrUpdateISSCmdConfig.PushGit, "push-git", false, "push git tag")
-	updateISSCmd.Flags().StringVar(&obrUpdateISSCmdConfig.GitMessage, "git-message", "", "git version message")
+	for _, c := range []*cobra.Command{updateISSCmd, updateAppCmd} {
+		c.Flags().Bool("push-git", false, "commit, tag and push")
+		c.Flags().StringP("git-message", "m", "", "commit message template (default \"update version {{ old }} --> {{ new }}\")")
+		c.Flags().String("tag-message", "", "tag message template (default \"{{ message }}\")")
+	}
 
-	updateAppCmd.Flags().StringVarP(&obrUpdateAppCmdConfig.Version, "version", "v", "+", "git version message")
-	updateAppCmd.Flags().StringVarP(&obrUpdateAppCmdConfig.IssPath, "iss-path", "", "C:\\dist\\chemical_server.iss", "iss_path")
+	updateISSCmd.Flags().StringVarP(&obrUpdateISSCmdConfig.Version, "version", "v", "+", "new version operator: +, ++, +++, auto (from conventional commits since the last tag) or a version such as 2.0.0")
+	updateISSCmd.Flags().String("iss-path", "", "iss file")
+	updateISSCmd.Flags().String("version-key", "", "iss key of the version, define:Name or setup:Key (default define:"+obr.ISSVersionDefine+")")
 
-	updateAppCmd.Flags().BoolVarP(&obrUpdateAppCmdConfig.PushGit, "push-git", "", false, "push git tag")
-	updateAppCmd.Flags().StringVarP(&obrUpdateAppCmdConfig.GitMessage, "git-message", "m", "", "git version message")
+	updateAppCmd.Flags().StringVarP(&obrUpdateAppCmdConfig.Version, "version", "v", "+", "new version operator: +, ++, +++, auto (from conventional commits since the last tag) or a version such as 2.0.0")
+	updateAppCmd.Flags().String("version-file", "", "version file (default VERSION)")
 }
 
 var obrCmd = &cobra.Command{
 	Use:   "obr",
 	Short: "obr tools",
 	Long:  `obr tools.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return loadOBRConfig(cmd)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		fmt.Println(cmd.UsageString())
 		return nil
@@ -63,59 +76,34 @@ var updateISSCmd = &cobra.Command{
 	Use:   "update-iss",
 	Short: "Update ISS version",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// 获取当前目录
-		originalDir, err := os.Getwd()
+		issPath, err := obrISSPath()
 		if err != nil {
-			return fmt.Errorf("error getting current directory: %v", err)
-		}
-		fmt.Println("Current directory:", originalDir)
-
-		// 获取绝对路径
-		issDir := filepath.Dir(obrUpdateISSCmdConfig.IssPath)
-		fmt.Println("Changing to ISS directory:", issDir)
-
-		// 切换到 ISS 文件所在目录
-		if err := os.Chdir(issDir); err != nil {
-			return fmt.Errorf("error changing directory: %v", err)
+			return err
 		}
+		// git 和钩子在 ISS 文件所在目录执行
+		dir := filepath.Dir(issPath)
+		git := obrGit(dir)
 
-		// 你的更新 ISS 版本的逻辑
-		fmt.Println("ISS version updated.")
-
-		version, err := obr.GetCurrentISSVersion(obrUpdateISSCmdConfig.IssPath)
+		currentVersion, err := obr.GetISSVersion(issPath, obrConfig.VersionKey)
 		if err != nil {
 			return err
 		}
-		versionManager := manager.NewVersionManager(manager.WithVersion(version))
-		currentVersion := versionManager.GetVersion()
-
-		if err := versionManager.SetVersion(obrUpdateISSCmdConfig.Version); err != nil {
-			return fmt.Errorf("error updating version: %v", err)
+		newVersion, err := bumpVersion(git, currentVersion, obrUpdateISSCmdConfig.Version)
+		if err != nil {
+			return err
 		}
-		newVersion := versionManager.GetVersion()
-		fmt.Printf("update version %s ---> %s", currentVersion, newVersion)
-		if err := obr.SaveISSVersion(newVersion, obrUpdateISSCmdConfig.IssPath); err != nil {
-			return fmt.Errorf("error saving version: %v", err)
+		fmt.Printf("update version %s ---> %s\n", currentVersion, newVersion)
+		tagName, err := obrConfig.Tag(newVersion, obr.ISSTagTemplate)
+		if err != nil {
+			return err
 		}
-
-		if obrUpdateISSCmdConfig.PushGit {
-			commitMessage := fmt.Sprintf("update version %s --> %s", currentVersion, newVersion)
-			if obrUpdateISSCmdConfig.GitMessage != "" {
-				commitMessage = obrUpdateISSCmdConfig.GitMessage
-			}
-			if err := obr.GitCommitChange(commitMessage); err != nil {
-				return err
-			}
-			if err := obr.GitTagAndPush(newVersion, obrUpdateISSCmdConfig.GitMessage); err != nil {
-				return err
-			}
+		if err := preflight(git, []string{issPath}, tagName, newVersion); err != nil {
+			return err
 		}
 
-		// 切换回原始目录
-		if err := os.Chdir(originalDir); err != nil {
-			return fmt.Errorf("error changing back to original directory: %v", err)
-		}
-		return nil
+		return runRelease(cmd, git, dir, currentVersion, newVersion, tagName, issPath, func() error {
+			return obr.SaveISSVersionKey(newVersion, issPath, obrConfig.VersionKey)
+		})
 	},
 }
 
@@ -124,40 +112,184 @@ var updateAppCmd = &cobra.Command{
 	Use:   "update-app",
 	Short: "Update App version",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		originalDir, err := os.Getwd()
+		versionFile, err := filepath.Abs(obrConfig.VersionFile)
+		if err != nil {
+			return err
+		}
+		dir := filepath.Dir(versionFile)
+		git := obrGit(dir)
+
+		currentVersion, err := obr.GetAppVersion(versionFile)
 		if err != nil {
-			return fmt.Errorf("error getting current directory: %v", err)
+			return err
 		}
-		version, err := obr.GetAppCurrentVersion(originalDir)
+		newVersion, err := bumpVersion(git, currentVersion, obrUpdateAppCmdConfig.Version)
 		if err != nil {
 			return err
 		}
-		versionManager := manager.NewVersionManager(manager.WithVersion(version))
-		currentVersion := versionManager.GetVersion()
+		fmt.Printf("update version %s ---> %s\n", currentVersion, newVersion)
+		tagName, err := obrConfig.Tag(newVersion, obr.AppTagTemplate)
+		if err != nil {
+			return err
+		}
+		if err := preflight(git, []string{versionFile}, tagName, newVersion); err != nil {
+			return err
+		}
 
-		if err := versionManager.SetVersion(obrUpdateAppCmdConfig.Version); err != nil {
-			return fmt.Errorf("error updating version: %v", err)
+		return runRelease(cmd, git, dir, currentVersion, newVersion, tagName, versionFile, func() error {
+			return obr.SaveAppVersion(newVersion, versionFile)
+		})
+	},
+}
+
+// obrISSPath 返回配置的 ISS 文件
+func obrISSPath() (string, error) {
+	if obrConfig.IssPath == "" {
+		return "", fmt.Errorf("iss path is not set, use --iss-path or iss-path in %s", obrCmdConfig.ConfigFile)
+	}
+	return obrConfig.IssPath, nil
+}
+
+// preflight 发布前检查，files 为命令会修改的文件，推送时检查本地和远程标签
+func preflight(git obr.Git, files []string, tagName, newVersion string) error {
+	if obrCmdConfig.SkipCheck {
+		fmt.Println("preflight checks skipped")
+		return nil
+	}
+	// 之前未推送或失败的发布追加的记录允许存在，推送后随记录一起提交
+	if releaseFile, err := obrReleaseFile(); err == nil {
+		files = append(files, releaseFile)
+	}
+	p := &obr.Preflight{
+		Git:         git,
+		Files:       files,
+		Branches:    obrConfig.Branches,
+		Version:     newVersion,
+		TagTemplate: obrConfig.TagTemplate,
+	}
+	// 只有推送时才会创建标签
+	if obrConfig.Push {
+		p.Tag = tagName
+		p.Remote = true
+	}
+	errs := p.Check()
+	for _, err := range errs {
+		fmt.Println("preflight:", err)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d preflight check(s) failed, use --skip-check to skip", len(errs))
+	}
+	return nil
+}
+
+// runRelease 在 dir 中执行钩子并调用 write 写入版本号，配置了 push 时提交、打标签并推送。
+// 钩子改动的文件随发布提交。post-push 之前的任一步骤失败都会回滚，post-push 失败时已经推送，不再回滚。
+// 发布记录在结束后追加，推送成功时记录单独提交。
+func runRelease(cmd *cobra.Command, git obr.Git, dir string, currentVersion, newVersion, tagName, file string, write func() error) error {
+	started := time.Now()
+	release := obr.NewRelease(git, obrCmdConfig.DryRun)
+	release.Hooks = obrConfig.Hooks
+	release.Dir = dir
+	release.Env = obr.HookEnv(currentVersion, newVersion, tagName)
+	releaseFile, err := obrReleaseFile()
+	if err != nil {
+		return err
+	}
+
+	err = release.Run(func(r *obr.Release) error {
+		if err := r.RunHook(obr.HookPreBump); err != nil {
+			return err
 		}
-		newVersion := versionManager.GetVersion()
-		fmt.Printf("update version %s ---> %s", currentVersion, newVersion)
-		versionFile := filepath.Join(originalDir, "VERSION")
-		if err := versionManager.SaveAs(versionFile, true); err != nil {
+		if err := r.WriteFile(file, write); err != nil {
 			return fmt.Errorf("error saving version: %v", err)
 		}
+		if err := r.RunHook(obr.HookPostBump); err != nil {
+			return err
+		}
+		if !obrConfig.Push {
+			return nil
+		}
+		return releaseGit(r, currentVersion, newVersion, tagName)
+	})
+	pushed := err == nil && obrConfig.Push
+	var hookErr error
+	if pushed {
+		hookErr = release.RunHook(obr.HookPostPush)
+	}
+	if obrCmdConfig.DryRun {
+		if err != nil {
+			return err
+		}
+		return hookErr
+	}
 
-		if obrUpdateAppCmdConfig.PushGit {
-			commitMessage := fmt.Sprintf("update version %s --> %s", currentVersion, newVersion)
-			if obrUpdateAppCmdConfig.GitMessage != "" {
-				commitMessage = obrUpdateAppCmdConfig.GitMessage
-			}
-			if err := obr.GitCommitChange(commitMessage); err != nil {
-				return err
-			}
-			tagName := "v" + newVersion
-			if err := obr.GitTagAndPush(tagName, obrUpdateISSCmdConfig.GitMessage); err != nil {
-				return err
-			}
+	rec := obr.NewReleaseRecord(release, filepath.Dir(releaseFile), err)
+	rec.Command = cmd.Name()
+	rec.OldVersion = currentVersion
+	rec.NewVersion = newVersion
+	if obrConfig.Push {
+		rec.Tag = tagName
+	}
+	rec.Started = started
+	var recordErr error
+	if pushed {
+		// 已经推送，post-push 失败只记录错误，状态仍为 released
+		if hookErr != nil {
+			rec.Error = hookErr.Error()
 		}
-		return nil
-	},
+		recordErr = obr.CommitReleaseRecord(git, releaseFile, rec)
+	} else {
+		recordErr = obr.AppendReleaseRecord(releaseFile, rec)
+	}
+	if recordErr != nil {
+		fmt.Printf("warning: write %s failed: %v\n", releaseFile, recordErr)
+	}
+	if hookErr != nil {
+		return fmt.Errorf("%v (already pushed, not rolled back)", hookErr)
+	}
+	return err
+}
+
+// releaseGit 提交、打标签并推送，失败时由 Release.Run 回滚
+func releaseGit(r *obr.Release, currentVersion, newVersion, tagName string) error {
+	commitMessage, tagMessage, err := obrConfig.Messages(currentVersion, newVersion, tagName)
+	if err != nil {
+		return err
+	}
+	if err := r.Commit(commitMessage); err != nil {
+		return err
+	}
+	if err := r.RunHook(obr.HookPreTag); err != nil {
+		return err
+	}
+	if err := r.Tag(tagName, tagMessage); err != nil {
+		return err
+	}
+	return r.PushTags()
+}
+
+// obrConfigDir 返回配置文件所在目录，配置中的相对路径基于该目录
+func obrConfigDir() string {
+	if abs, err := filepath.Abs(obrCmdConfig.ConfigFile); err == nil {
+		return filepath.Dir(abs)
+	}
+	return filepath.Dir(obrCmdConfig.ConfigFile)
+}
+
+// obrReleaseFile 返回发布记录文件的绝对路径
+func obrReleaseFile() (string, error) {
+	return obrConfig.ReleasePath(obrConfigDir())
+}
+
+// bumpVersion 按操作符计算新版本号，auto 根据上一个匹配 tag-template 的标签之后的提交决定升级级别
+func bumpVersion(git obr.Git, currentVersion string, op string) (string, error) {
+	return obr.NextVersion(git, currentVersion, op, obrConfig.TagTemplate, os.Stdout)
+}
+
+// obrGit 返回在 dir 中执行的 Git，--dry-run 时只输出命令
+func obrGit(dir string) obr.Git {
+	git := obr.NewExecGit(dir, obrCmdConfig.DryRun)
+	git.Remote = obrConfig.Remote
+	git.Sign = obrConfig.SignTags
+	return git
 }
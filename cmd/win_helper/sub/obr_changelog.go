@@ -0,0 +1,141 @@
+package sub
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"win_helper/pkg/obr"
+)
+
+type OBRChangelogCmdConfig struct {
+	Version      string
+	From         string
+	To           string
+	Stdout       bool
+	IncludeOther bool
+	Tag          bool
+	PushGit      bool
+}
+
+var obrChangelogCmdConfig = &OBRChangelogCmdConfig{}
+
+func init() {
+	obrCmd.AddCommand(changelogCmd)
+
+	changelogCmd.Flags().StringVarP(&obrChangelogCmdConfig.Version, "version", "v", "", "release version (default: VERSION file, or Unreleased)")
+	changelogCmd.Flags().StringVar(&obrChangelogCmdConfig.From, "from", "", "start tag, exclusive (default: previous tag)")
+	changelogCmd.Flags().StringVar(&obrChangelogCmdConfig.To, "to", "HEAD", "end ref")
+	changelogCmd.Flags().StringP("file", "f", "", "changelog file (default "+obr.ChangelogFile+")")
+	changelogCmd.Flags().BoolVar(&obrChangelogCmdConfig.Stdout, "stdout", false, "print changelog instead of writing the file")
+	changelogCmd.Flags().BoolVar(&obrChangelogCmdConfig.IncludeOther, "all", false, "include docs/chore/... and non-conventional commits under Other")
+	changelogCmd.Flags().BoolVar(&obrChangelogCmdConfig.Tag, "tag", false, "commit the changelog and create an annotated tag (see tag-template, default v{{ version }}) with it as message")
+	changelogCmd.Flags().BoolVar(&obrChangelogCmdConfig.PushGit, "push-tag", false, "push the tag, implies --tag")
+}
+
+// 生成更新日志子命令
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "Generate changelog from git history",
+	Long: `Generate changelog from git history.
+
+收集上一个标签到 HEAD 之间的提交, 按 Conventional Commits 类型分组, 生成 Keep a Changelog 格式的 markdown 并插入到 CHANGELOG.md 开头。`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := obrChangelogCmdConfig
+		if c.Version == "" {
+			c.Version = "Unreleased"
+			if wd, err := os.Getwd(); err == nil {
+				if version, err := obr.GetAppCurrentVersion(wd); err == nil {
+					c.Version = strings.TrimSpace(version)
+				}
+			}
+		}
+		git := obrGit("")
+		// 写入 CHANGELOG 之前检查要创建的标签
+		tagName := ""
+		if c.Tag || c.PushGit {
+			if c.Version == "Unreleased" {
+				return fmt.Errorf("--tag requires a version")
+			}
+			var err error
+			if tagName, err = obrConfig.Tag(c.Version, obr.AppTagTemplate); err != nil {
+				return err
+			}
+			if !obrCmdConfig.SkipCheck {
+				check := &obr.Preflight{Git: git, Tag: tagName, Remote: c.PushGit}
+				if err := check.CheckTag(); err != nil {
+					return err
+				}
+			}
+		}
+		from := c.From
+		if !cmd.Flags().Changed("from") {
+			var err error
+			if from, err = git.LastTag(c.To, obr.TagPatterns(obrConfig.TagTemplate)...); err != nil {
+				return err
+			}
+		}
+		commits, err := git.Log(from, c.To)
+		if err != nil {
+			return err
+		}
+		date := ""
+		if c.Version != "Unreleased" {
+			date = time.Now().Format("2006-01-02")
+		}
+		changelog := obr.NewChangelog(c.Version, date, obr.ParseConventionalCommits(commits), c.IncludeOther)
+		content, err := changelog.Render()
+		if err != nil {
+			return err
+		}
+
+		if c.Stdout || obrCmdConfig.DryRun {
+			fmt.Print(content)
+		} else {
+			if err := obr.PrependChangelog(obrConfig.ChangelogFile, content); err != nil {
+				return err
+			}
+			rangeName := from
+			if rangeName == "" {
+				rangeName = "(root)"
+			}
+			fmt.Printf("%d commit(s) %s..%s written to %s\n", len(commits), rangeName, c.To, obrConfig.ChangelogFile)
+		}
+
+		if tagName != "" {
+			// 标签指向包含本次更新日志的提交
+			if !c.Stdout {
+				if err := commitChangelog(git, c.Version); err != nil {
+					return err
+				}
+			}
+			if c.PushGit {
+				return obr.TagAndPush(git, tagName, content)
+			}
+			return git.Tag(tagName, content)
+		}
+		return nil
+	},
+}
+
+// commitChangelog 提交更新日志，文件没有改动时不提交
+func commitChangelog(git obr.Git, version string) error {
+	changelogFile, err := filepath.Abs(obrConfig.ChangelogFile)
+	if err != nil {
+		return err
+	}
+	message := fmt.Sprintf("update changelog for %s", version)
+	// --dry-run 时没有写入文件，只输出命令
+	if obrCmdConfig.DryRun {
+		if err := git.Add(changelogFile); err != nil {
+			return err
+		}
+		return git.Commit(message)
+	}
+	_, err = obr.CommitChanged(git, message, changelogFile)
+	return err
+}
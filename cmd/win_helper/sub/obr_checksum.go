@@ -0,0 +1,155 @@
+package sub
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/gookit/goutil/fsutil"
+	"github.com/spf13/cobra"
+
+	"win_helper/pkg/obr"
+)
+
+type OBRChecksumCmdConfig struct {
+	Version       string
+	Sums          string
+	Manifest      string
+	InstallerOnly bool
+	Json          bool
+}
+
+var obrChecksumCmdConfig = &OBRChecksumCmdConfig{}
+
+func init() {
+	obrCmd.AddCommand(obrChecksumCmd)
+	obrCmd.AddCommand(obrVerifyCmd)
+
+	for _, c := range []*cobra.Command{obrChecksumCmd, obrVerifyCmd} {
+		c.Flags().String("iss-path", "", "iss file, dir defaults to its OutputDir and installers are matched by OutputBaseFilename")
+		c.Flags().String("version-key", "", "iss key of the version (default define:"+obr.ISSVersionDefine+")")
+		c.Flags().StringVar(&obrChecksumCmdConfig.Sums, "sums", obr.ChecksumFile, "checksum file name")
+		c.Flags().StringVar(&obrChecksumCmdConfig.Manifest, "manifest", obr.ManifestFile, "json manifest file name, empty to skip")
+	}
+	obrChecksumCmd.Flags().StringVarP(&obrChecksumCmdConfig.Version, "version", "v", "", "version recorded in the manifest (default version from the iss file)")
+	obrChecksumCmd.Flags().BoolVar(&obrChecksumCmdConfig.InstallerOnly, "installer-only", false, "only include installers matching OutputBaseFilename")
+	obrVerifyCmd.Flags().BoolVar(&obrChecksumCmdConfig.Json, "json", false, "machine-readable json output")
+}
+
+var obrChecksumCmd = &cobra.Command{
+	Use:   "checksum [dir]",
+	Short: "Write SHA256SUMS and a json manifest for release artifacts",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, naming, err := checksumTarget(args)
+		if err != nil {
+			return err
+		}
+		version := obrChecksumCmdConfig.Version
+		if version == "" && naming != nil {
+			version = naming.Version
+		}
+		manifest, err := obr.NewArtifactManifest(dir, naming, version, obrChecksumCmdConfig.InstallerOnly, obrChecksumCmdConfig.Sums, obrChecksumCmdConfig.Manifest)
+		if err != nil {
+			return err
+		}
+		if naming != nil && !manifest.HasInstaller(naming.InstallerName()) {
+			fmt.Printf("warning: installer %s not found in %s\n", naming.InstallerName(), dir)
+		}
+
+		if obrCmdConfig.DryRun {
+			for _, a := range manifest.Files {
+				fmt.Printf("%s  %s\n", a.SHA256, a.File)
+			}
+			return nil
+		}
+		if err := obr.WriteChecksums(dir, obrChecksumCmdConfig.Sums, obrChecksumCmdConfig.Manifest, manifest); err != nil {
+			return err
+		}
+		fmt.Printf("%d file(s) written to %s\n", len(manifest.Files), filepath.Join(dir, obrChecksumCmdConfig.Sums))
+		return nil
+	},
+}
+
+var obrVerifyCmd = &cobra.Command{
+	Use:   "verify [dir]",
+	Short: "Verify release artifacts against SHA256SUMS and the json manifest",
+	Args:  cobra.MaximumNArgs(1),
+	// 校验失败时不输出用法
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, naming, err := checksumTarget(args)
+		if err != nil {
+			return err
+		}
+		results, err := obr.VerifyChecksums(dir, obrChecksumCmdConfig.Sums, obrChecksumCmdConfig.Manifest)
+		if err != nil {
+			return err
+		}
+		// 当前版本的安装包应当在校验文件中
+		if naming != nil {
+			found := false
+			for _, r := range results {
+				if filepath.Base(r.File) == naming.InstallerName() {
+					found = true
+					break
+				}
+			}
+			if !found {
+				results = append(results, &obr.VerifyResult{File: naming.InstallerName(), Status: obr.VerifyMissing, Detail: "installer not listed"})
+			}
+		}
+
+		failed := 0
+		for _, r := range results {
+			if !r.OK() {
+				failed++
+			}
+		}
+		if obrChecksumCmdConfig.Json {
+			if err := printJson(results); err != nil {
+				return err
+			}
+		} else {
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "STATUS\tFILE\tDETAIL")
+			for _, r := range results {
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", r.Status, r.File, r.Detail)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d file(s) failed verification", failed, len(results))
+		}
+		return nil
+	},
+}
+
+// checksumTarget 返回要校验的目录，配置了 ISS 时按 OutputBaseFilename 识别安装包，未指定目录时使用 OutputDir
+func checksumTarget(args []string) (string, *obr.ArtifactNaming, error) {
+	dir := ""
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	var naming *obr.ArtifactNaming
+	if obrConfig.IssPath != "" {
+		f, err := obr.LoadISS(obrConfig.IssPath)
+		if err != nil {
+			return "", nil, err
+		}
+		naming = obr.ISSNaming(f, obrConfig.VersionKey)
+		if dir == "" {
+			dir = f.OutputDir(obrConfig.IssPath)
+		}
+	}
+	if dir == "" {
+		return "", nil, fmt.Errorf("dir is required when iss path is not set")
+	}
+	if !fsutil.DirExist(dir) {
+		return "", nil, fmt.Errorf("dir %s does not exist", dir)
+	}
+	return dir, naming, nil
+}
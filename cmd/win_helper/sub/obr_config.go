@@ -0,0 +1,122 @@
+package sub
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/gookit/goutil/fsutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+
+	"win_helper/pkg/obr"
+)
+
+// obrConfigFlags 命令行参数对应的配置项，设置了参数时覆盖配置文件
+var obrConfigFlags = map[string]string{
+	"iss-path":     "iss-path",
+	"version-key":  "version-key",
+	"version-file": "version-file",
+	"tag-template": "tag-template",
+	"remote":       "remote",
+	"branch":       "branches",
+	"sign-tags":    "sign-tags",
+	"push-git":     "push",
+	"git-message":  "commit-message",
+	"tag-message":  "tag-message",
+	"file":         "changelog-file",
+}
+
+// obrConfig 生效的配置，在 obr 命令执行前加载
+var obrConfig = obr.DefaultConfig()
+
+func init() {
+	obrCmd.AddCommand(obrConfigCmd)
+	obrConfigCmd.AddCommand(obrConfigShowCmd)
+}
+
+// loadOBRConfig 按 默认值 < .obr.yaml < 命令行参数 的顺序加载配置
+func loadOBRConfig(cmd *cobra.Command) error {
+	v := viper.New()
+	v.SetConfigType("yaml")
+
+	// 默认值
+	defaults, err := yaml.Marshal(obr.DefaultConfig())
+	if err != nil {
+		return err
+	}
+	var defaultMap map[string]any
+	if err := yaml.Unmarshal(defaults, &defaultMap); err != nil {
+		return err
+	}
+	for key, value := range defaultMap {
+		v.SetDefault(key, value)
+	}
+
+	filename := obrCmdConfig.ConfigFile
+	if fsutil.FileExist(filename) {
+		v.SetConfigFile(filename)
+		if err := v.ReadInConfig(); err != nil {
+			return fmt.Errorf("解析配置文件 %s 失败: %v", filename, err)
+		}
+	} else if cmd.Flags().Changed("config") {
+		return fmt.Errorf("config file %s does not exist", filename)
+	}
+
+	for flag, key := range obrConfigFlags {
+		if f := cmd.Flags().Lookup(flag); f != nil {
+			if err := v.BindPFlag(key, f); err != nil {
+				return err
+			}
+		}
+	}
+
+	config := &obr.Config{}
+	if err := v.Unmarshal(config); err != nil {
+		return fmt.Errorf("解析配置文件 %s 失败: %v", filename, err)
+	}
+	// 命令行参数中的相对路径基于当前目录，配置文件和默认值中的基于配置文件所在目录
+	for flag, p := range map[string]*string{"iss-path": &config.IssPath, "version-file": &config.VersionFile, "file": &config.ChangelogFile} {
+		if f := cmd.Flags().Lookup(flag); f != nil && f.Changed && *p != "" {
+			if *p, err = filepath.Abs(*p); err != nil {
+				return err
+			}
+		}
+	}
+	if err := config.ResolvePaths(obrConfigDir()); err != nil {
+		return err
+	}
+	obrConfig = config
+	return nil
+}
+
+var obrConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "obr settings",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Println(cmd.UsageString())
+		return nil
+	},
+}
+
+var obrConfigShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective obr settings",
+	Long: `Print the effective obr settings.
+
+配置按 默认值 < .obr.yaml < 命令行参数 的顺序覆盖, 输出可以直接作为 .obr.yaml 使用。`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		source := "not found, using defaults"
+		if fsutil.FileExist(obrCmdConfig.ConfigFile) {
+			source = "loaded"
+		}
+		fmt.Printf("# config file: %s (%s)\n", obrCmdConfig.ConfigFile, source)
+		// 路径在加载时已转换为绝对路径，输出时还原为相对于配置文件所在目录的路径
+		out, err := yaml.Marshal(obrConfig.RelativePaths(obrConfigDir()))
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(out))
+		return nil
+	},
+}
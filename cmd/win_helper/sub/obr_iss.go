@@ -0,0 +1,154 @@
+package sub
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"win_helper/pkg/obr"
+)
+
+type OBRISSCmdConfig struct {
+	Json   bool
+	Expand bool
+	Create bool
+}
+
+var obrISSCmdConfig = &OBRISSCmdConfig{}
+
+func init() {
+	obrCmd.AddCommand(obrISSCmd)
+	obrISSCmd.AddCommand(obrISSGetCmd)
+	obrISSCmd.AddCommand(obrISSSetCmd)
+	obrISSCmd.AddCommand(obrISSCheckCmd)
+
+	obrISSCmd.PersistentFlags().String("iss-path", "", "iss file (default iss-path in .obr.yaml)")
+	obrISSCmd.PersistentFlags().BoolVar(&obrISSCmdConfig.Json, "json", false, "machine-readable json output")
+
+	obrISSGetCmd.Flags().BoolVar(&obrISSCmdConfig.Expand, "expand", false, "expand {#Name} references")
+	obrISSSetCmd.Flags().BoolVar(&obrISSCmdConfig.Create, "create", false, "create the key if it does not exist, requires define: or setup: prefix")
+}
+
+var obrISSCmd = &cobra.Command{
+	Use:   "iss",
+	Short: "read and modify inno setup scripts",
+	Long: `read and modify inno setup scripts.
+
+keys address #define constants and [Setup] directives:
+  define:MyAppName   #define MyAppName
+  setup:AppId        [Setup] AppId
+  MyAppName          #define first, then [Setup]`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Println(cmd.UsageString())
+		return nil
+	},
+}
+
+var obrISSGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "get a #define or [Setup] value",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		issPath, err := obrISSPath()
+		if err != nil {
+			return err
+		}
+		v, err := obr.GetISSValue(issPath, args[0])
+		if err != nil {
+			return err
+		}
+		if obrISSCmdConfig.Json {
+			return printJson(v)
+		}
+		if obrISSCmdConfig.Expand {
+			fmt.Println(v.Expanded)
+		} else {
+			fmt.Println(v.Value)
+		}
+		return nil
+	},
+}
+
+var obrISSSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "set a #define or [Setup] value",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		issPath, err := obrISSPath()
+		if err != nil {
+			return err
+		}
+		old, err := obr.SaveISSValue(issPath, args[0], args[1], obrISSCmdConfig.Create)
+		if err != nil {
+			return err
+		}
+		if obrISSCmdConfig.Json {
+			return printJson(map[string]string{
+				"kind":     old.Kind,
+				"name":     old.Name,
+				"oldValue": old.Value,
+				"value":    args[1],
+			})
+		}
+		fmt.Printf("%s %s: %s ---> %s\n", old.Kind, old.Name, old.Value, args[1])
+		return nil
+	},
+}
+
+var obrISSCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "check [Files] sources, exits non-zero when problems are found",
+	Long: `check [Files] sources, exits non-zero when problems are found.
+
+every Source is resolved relative to SourceDir after expanding {#Name} and ISPP built-ins such as {#SourcePath},
+reports missing files, wildcards without matches and files installed to the same destination.
+entries with the external or skipifsourcedoesntexist flag are not reported,
+entries using built-ins only known to ISCC, such as {#CompilerPath}, are skipped with a warning,
+entries with Components, Tasks, Languages or Check are not checked for duplicate destinations.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		issPath, err := obrISSPath()
+		if err != nil {
+			return err
+		}
+		f, err := obr.LoadISS(issPath)
+		if err != nil {
+			return err
+		}
+		result := obr.CheckISSFiles(f, issPath)
+		if obrISSCmdConfig.Json {
+			if err := printJson(result); err != nil {
+				return err
+			}
+		} else {
+			for _, e := range result.Entries {
+				if strings.HasPrefix(e.Skipped, "{#") {
+					fmt.Printf("%s:%d: warning: %s not checked, %s is only known to ISCC\n", issPath, e.Line, e.Source, e.Skipped)
+				}
+			}
+			for _, p := range result.Problems {
+				fmt.Printf("%s:%d: %s\n", issPath, p.Line, p)
+			}
+			files := 0
+			for _, e := range result.Entries {
+				files += len(e.Files)
+			}
+			fmt.Printf("%d entries, %d files, %d problem(s), source dir %s\n", len(result.Entries), files, len(result.Problems), result.SourceDir)
+		}
+		if !result.OK() {
+			return fmt.Errorf("%d problem(s) found in [Files]", len(result.Problems))
+		}
+		return nil
+	},
+}
+
+func printJson(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,98 @@
+package sub
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"win_helper/pkg/obr"
+)
+
+type OBRReleaseCmdConfig struct {
+	Resume  bool
+	Restart bool
+	Plan    bool
+}
+
+var obrReleaseCmdConfig = &OBRReleaseCmdConfig{}
+
+func init() {
+	obrCmd.AddCommand(obrReleaseCmd)
+
+	obrReleaseCmd.Flags().BoolVar(&obrReleaseCmdConfig.Resume, "resume", false, "continue a failed release from the failing step")
+	obrReleaseCmd.Flags().BoolVar(&obrReleaseCmdConfig.Restart, "restart", false, "discard the state of a failed release and start over")
+	obrReleaseCmd.Flags().BoolVar(&obrReleaseCmdConfig.Plan, "plan", false, "print the steps without running them")
+}
+
+var obrReleaseCmd = &cobra.Command{
+	Use:   "release",
+	Short: "Run the release pipeline from .obr.yaml",
+	Long: `Run the release pipeline from .obr.yaml.
+
+按 pipeline 中的步骤发布, 内置步骤: bump, sync-version, changelog, commit, tag, push, checksum, 其他步骤使用 run 在配置文件所在目录执行命令。
+每步完成后保存状态到 .obr/release-state.json, 失败后修复问题并使用 --resume 从失败的步骤继续, 已完成的步骤不会回滚。`,
+	Example: `  # .obr.yaml
+  pipeline:
+    - uses: bump
+      with: {target: iss, version: auto}
+    - uses: changelog
+    - uses: commit
+    - name: build installer
+      run: iscc setup.iss
+    - uses: checksum
+    - uses: tag
+      with: {message: changelog}
+    - uses: push`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := obrReleaseCmdConfig
+		if c.Resume && c.Restart {
+			return fmt.Errorf("--resume and --restart are mutually exclusive")
+		}
+		dir := obrConfigDir()
+		stateFile := filepath.Join(dir, obr.PipelineStateFile)
+		state, err := obr.LoadPipelineState(stateFile)
+		if err != nil {
+			return err
+		}
+		switch {
+		case c.Resume && state == nil:
+			return fmt.Errorf("no failed release to resume (%s not found)", stateFile)
+		case c.Restart:
+			state = nil
+			if !c.Plan && !obrCmdConfig.DryRun {
+				_ = os.Remove(stateFile)
+			}
+		case !c.Resume && state != nil && !c.Plan:
+			return fmt.Errorf("previous release failed at step %d (%s): %s\nuse --resume to continue or --restart to start over",
+				state.Next+1, state.Steps[state.Next], state.Error)
+		case !c.Resume:
+			state = nil
+		}
+
+		p, err := obr.NewPipeline(obrConfig, obrGit(dir), state)
+		if err != nil {
+			return err
+		}
+		p.Dir = dir
+		p.DryRun = obrCmdConfig.DryRun
+		p.SkipCheck = obrCmdConfig.SkipCheck
+		p.StateFile = stateFile
+
+		if c.Plan {
+			fmt.Print(p.Plan())
+			return nil
+		}
+		err = p.Run()
+		if releaseFile, absErr := obrReleaseFile(); absErr == nil {
+			if recordErr := p.WriteRecord(releaseFile, err); recordErr != nil {
+				fmt.Printf("warning: write %s failed: %v\n", releaseFile, recordErr)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("%v\nfix the problem and run `obr release --resume`", err)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,87 @@
+package sub
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"win_helper/pkg/obr"
+)
+
+type OBRReleasesCmdConfig struct {
+	Json  bool
+	Limit int
+}
+
+var obrReleasesCmdConfig = &OBRReleasesCmdConfig{}
+
+func init() {
+	obrCmd.AddCommand(obrReleasesCmd)
+	obrReleasesCmd.AddCommand(obrReleasesListCmd)
+
+	obrReleasesListCmd.Flags().BoolVar(&obrReleasesCmdConfig.Json, "json", false, "machine-readable json output")
+	obrReleasesListCmd.Flags().IntVarP(&obrReleasesCmdConfig.Limit, "limit", "n", 0, "show only the latest n releases")
+}
+
+var obrReleasesCmd = &cobra.Command{
+	Use:   "releases",
+	Short: "release history recorded in release.json",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Println(cmd.UsageString())
+		return nil
+	},
+}
+
+var obrReleasesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List recorded releases, newest first",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		releaseFile, err := obrReleaseFile()
+		if err != nil {
+			return err
+		}
+		records, err := obr.LoadReleaseRecords(releaseFile)
+		if err != nil {
+			return err
+		}
+		// 最新的在前
+		for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+			records[i], records[j] = records[j], records[i]
+		}
+		if n := obrReleasesCmdConfig.Limit; n > 0 && n < len(records) {
+			records = records[:n]
+		}
+		if obrReleasesCmdConfig.Json {
+			if records == nil {
+				records = []*obr.ReleaseRecord{}
+			}
+			return printJson(records)
+		}
+		if len(records) == 0 {
+			fmt.Printf("no releases recorded in %s\n", releaseFile)
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "FINISHED\tCOMMAND\tVERSION\tTAG\tCOMMIT\tSTATUS\tUSER")
+		for _, r := range records {
+			commit := r.Commit
+			if len(commit) > 7 {
+				commit = commit[:7]
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s -> %s\t%s\t%s\t%s\t%s\n",
+				r.Finished.Local().Format("2006-01-02 15:04:05"), r.Command, r.OldVersion, r.NewVersion,
+				dash(r.Tag), dash(commit), r.Status, r.User)
+		}
+		return w.Flush()
+	},
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
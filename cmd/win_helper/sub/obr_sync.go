@@ -0,0 +1,91 @@
+package sub
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"win_helper/pkg/obr"
+)
+
+type OBRSyncVersionCmdConfig struct {
+	Version string
+}
+
+var obrSyncVersionCmdConfig = &OBRSyncVersionCmdConfig{}
+
+func init() {
+	obrCmd.AddCommand(syncVersionCmd)
+
+	syncVersionCmd.Flags().StringVarP(&obrSyncVersionCmdConfig.Version, "version", "v", "=", "new version such as 2.0.0 or operator (+, ++, +++, auto), applied to the source first")
+}
+
+// 同步版本号子命令
+var syncVersionCmd = &cobra.Command{
+	Use:   "sync-version",
+	Short: "Sync version from source to all targets",
+	Long: `Sync version from source to all targets.
+
+读取配置文件中的 sync-version, 将 source 中的版本号写入所有 targets。
+format 支持 file, regex, python, json, toml, iss, 为空时根据文件名推断。`,
+	Example: `  # .obr.yaml
+  sync-version:
+    source: {path: VERSION}
+    targets:
+      - {path: setup.iss, key: "define:MyAppVersion"}
+      - {path: package.json}
+      - {path: pyproject.toml, key: tool.poetry.version}
+      - {path: app/__init__.py}
+      - {path: main.go, pattern: 'Version = "([^"]+)"'}`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sync := obrConfig.SyncVersion
+		if sync == nil {
+			return fmt.Errorf("%s: missing sync-version section", obrCmdConfig.ConfigFile)
+		}
+		if err := sync.Validate(); err != nil {
+			return err
+		}
+		// 相对路径基于配置文件所在目录
+		dir := filepath.Dir(obrCmdConfig.ConfigFile)
+
+		currentVersion, err := sync.Source.Read(dir)
+		if err != nil {
+			return fmt.Errorf("读取版本号失败: %v", err)
+		}
+		newVersion, err := bumpVersion(obrGit(dir), currentVersion, obrSyncVersionCmdConfig.Version)
+		if err != nil {
+			return err
+		}
+
+		source := &obr.SyncResult{Target: sync.Source, OldVersion: currentVersion, NewVersion: newVersion}
+		if source.Changed() && !obrCmdConfig.DryRun {
+			source.Err = sync.Source.Write(dir, newVersion)
+		}
+		results := append([]*obr.SyncResult{source}, sync.Sync(dir, newVersion, obrCmdConfig.DryRun)...)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "FILE\tOLD\tNEW\tSTATUS")
+		failed := 0
+		for _, r := range results {
+			status := "unchanged"
+			switch {
+			case r.Err != nil:
+				status = r.Err.Error()
+				failed++
+			case r.Changed() && obrCmdConfig.DryRun:
+				status = "pending"
+			case r.Changed():
+				status = "updated"
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Target, r.OldVersion, r.NewVersion, status)
+		}
+		_ = w.Flush()
+		if failed > 0 {
+			return fmt.Errorf("%d file(s) failed", failed)
+		}
+		return nil
+	},
+}
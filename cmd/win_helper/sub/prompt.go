@@ -0,0 +1,140 @@
+package sub
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Prompter 命令行交互式问答
+type Prompter struct {
+	reader *bufio.Reader
+	out    io.Writer
+}
+
+func NewPrompter(in io.Reader, out io.Writer) *Prompter {
+	return &Prompter{reader: bufio.NewReader(in), out: out}
+}
+
+// Ask 提问并校验回答，回答为空时使用默认值，校验失败时重新提问
+func (p *Prompter) Ask(label string, def string, validate func(string) error) (string, error) {
+	for {
+		if def != "" {
+			fmt.Fprintf(p.out, "%s [%s]: ", label, def)
+		} else {
+			fmt.Fprintf(p.out, "%s: ", label)
+		}
+		line, err := p.reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", fmt.Errorf("读取输入失败: %v", err)
+		}
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			answer = def
+		}
+		if validate != nil {
+			if err := validate(answer); err != nil {
+				fmt.Fprintf(p.out, "Invalid input: %v\n", err)
+				continue
+			}
+		}
+		return answer, nil
+	}
+}
+
+// Choose 从选项中选择一个，可以输入选项或序号
+func (p *Prompter) Choose(label string, options []string, def string) (string, error) {
+	for i, option := range options {
+		fmt.Fprintf(p.out, "  %d) %s\n", i+1, option)
+	}
+	answer, err := p.Ask(label, def, func(s string) error {
+		if chooseOption(options, s) == "" {
+			return fmt.Errorf("please choose one of %s", strings.Join(options, ", "))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return chooseOption(options, answer), nil
+}
+
+func chooseOption(options []string, answer string) string {
+	if i, err := strconv.Atoi(answer); err == nil && i >= 1 && i <= len(options) {
+		return options[i-1]
+	}
+	for _, option := range options {
+		if strings.EqualFold(option, answer) {
+			return option
+		}
+	}
+	return ""
+}
+
+// AskInt 提问并要求回答为非负整数
+func (p *Prompter) AskInt(label string, def int) (int, error) {
+	answer, err := p.Ask(label, strconv.Itoa(def), func(s string) error {
+		if n, err := strconv.Atoi(s); err != nil || n < 0 {
+			return fmt.Errorf("please enter a non-negative number")
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(answer)
+}
+
+// AskList 提问并将逗号分隔的回答拆分为列表，validate 对每一项进行校验
+func (p *Prompter) AskList(label string, def []string, validate func(string) error) ([]string, error) {
+	answer, err := p.Ask(label, strings.Join(def, ","), func(s string) error {
+		if validate == nil {
+			return nil
+		}
+		for _, item := range splitList(s) {
+			if err := validate(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return splitList(answer), nil
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Confirm 提问是否继续
+func (p *Prompter) Confirm(label string, def bool) (bool, error) {
+	defStr := "no"
+	if def {
+		defStr = "yes"
+	}
+	answer, err := p.Ask(label+" (yes/no)", defStr, func(s string) error {
+		switch strings.ToLower(s) {
+		case "yes", "y", "1", "true", "no", "n", "0", "false":
+			return nil
+		}
+		return fmt.Errorf("please enter 'yes' or 'no'")
+	})
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "yes", "y", "1", "true":
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,98 @@
+package sub
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"win_helper/pkg/schtask"
+)
+
+type SchTaskConfig struct {
+	Force            bool
+	OutDir           string
+	Name             string
+	Description      string
+	Author           string
+	Command          string
+	Arguments        string
+	WorkingDirectory string
+	Triggers         []string
+	StartDate        string
+	RunAs            string
+	Highest          bool
+	RetryCount       int
+	RetryInterval    time.Duration
+	TimeLimit        time.Duration
+}
+
+var schTaskConfig = SchTaskConfig{}
+
+func init() {
+	rootCmd.AddCommand(schTaskCmd)
+
+	schTaskCmd.Flags().StringVar(&schTaskConfig.Name, "name", "", "task name")
+	schTaskCmd.Flags().StringVar(&schTaskConfig.Description, "description", "", "description")
+	schTaskCmd.Flags().StringVar(&schTaskConfig.Author, "author", "", "author")
+	schTaskCmd.Flags().StringVar(&schTaskConfig.Command, "command", "", "command to run")
+	schTaskCmd.Flags().StringVar(&schTaskConfig.Arguments, "arguments", "", "arguments")
+	schTaskCmd.Flags().StringVar(&schTaskConfig.WorkingDirectory, "working-directory", "", "working directory")
+	schTaskCmd.Flags().StringArrayVar(&schTaskConfig.Triggers, "trigger", []string{}, "trigger(daily@HH:MM[/days]|weekly@Mon,Fri@HH:MM[/weeks]|startup[/PT1M]|logon[/PT1M][@DOMAIN\\user]|idle)")
+	schTaskCmd.Flags().StringVar(&schTaskConfig.StartDate, "start-date", "", "start date like 2006-01-02 (default: today)")
+	schTaskCmd.Flags().StringVar(&schTaskConfig.RunAs, "run-as", "SYSTEM", "run as account(SYSTEM|LOCAL SERVICE|NETWORK SERVICE|DOMAIN\\user)")
+	schTaskCmd.Flags().BoolVar(&schTaskConfig.Highest, "highest", false, "run with highest privileges")
+	schTaskCmd.Flags().IntVar(&schTaskConfig.RetryCount, "retry-count", 0, "restart count on failure")
+	schTaskCmd.Flags().DurationVar(&schTaskConfig.RetryInterval, "retry-interval", 5*time.Minute, "restart interval on failure")
+	schTaskCmd.Flags().DurationVar(&schTaskConfig.TimeLimit, "time-limit", 72*time.Hour, "execution time limit")
+	schTaskCmd.Flags().StringVarP(&schTaskConfig.OutDir, "out", "o", "./", "output directory")
+	schTaskCmd.Flags().BoolVar(&schTaskConfig.Force, "force", true, "force write")
+}
+
+var schTaskCmd = &cobra.Command{
+	Use:   "schtask-gen",
+	Short: "generate windows scheduled task xml and schtasks script",
+	Long:  `generate windows task scheduler 1.2 xml and a matching schtasks /Create script`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if schTaskConfig.Name == "" {
+			return fmt.Errorf("missing name")
+		}
+		if schTaskConfig.Command == "" {
+			return fmt.Errorf("missing command")
+		}
+		if len(schTaskConfig.Triggers) == 0 {
+			return fmt.Errorf("missing trigger")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		startDate := time.Now()
+		if schTaskConfig.StartDate != "" {
+			d, err := time.ParseInLocation("2006-01-02", schTaskConfig.StartDate, time.Local)
+			if err != nil {
+				return fmt.Errorf("invalid start date: %v", err)
+			}
+			startDate = d
+		}
+		t, err := schtask.NewTask(
+			schtask.WithBasePath(schTaskConfig.OutDir),
+			schtask.WithName(schTaskConfig.Name),
+			schtask.WithDescription(schTaskConfig.Description),
+			schtask.WithAuthor(schTaskConfig.Author),
+			schtask.WithCommand(schTaskConfig.Command),
+			schtask.WithArguments(schTaskConfig.Arguments),
+			schtask.WithWorkingDirectory(schTaskConfig.WorkingDirectory),
+			schtask.WithTriggers(schTaskConfig.Triggers),
+			schtask.WithStartDate(startDate),
+			schtask.WithRunAs(schTaskConfig.RunAs),
+			schtask.WithHighest(schTaskConfig.Highest),
+			schtask.WithRetry(schTaskConfig.RetryCount, schTaskConfig.RetryInterval),
+			schtask.WithTimeLimit(schTaskConfig.TimeLimit),
+			schtask.WithForce(schTaskConfig.Force),
+		)
+		if err != nil {
+			return err
+		}
+		return t.Generate()
+	},
+}
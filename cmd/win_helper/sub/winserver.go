@@ -28,12 +28,27 @@ type WinServiceConfig struct {
 	LogAutoRollAtTime string
 	LogSizeThreshold  int
 	LogKeepFiles      int
+
+	StopTimeout string
+	Runtime     string
+	Interpreter string
+	Venv        string
+	JvmOptions  []string
+	Heap        string
+	OpenPorts   []string
+
+	Manifest    string
+	Services    []string
+	EnvProfile  string
+	Print       bool
+	Interactive bool
 }
 
 var serverConfig = WinServiceConfig{}
 
 func init() {
 	rootCmd.AddCommand(serverCmd)
+	rootCmd.AddCommand(winserverCmd)
 
 	serverCmd.Flags().StringVar(&serverConfig.ID, "id", "", "Id(default=name)")
 	serverCmd.Flags().StringVar(&serverConfig.Name, "name", "", "name")
@@ -55,8 +70,18 @@ func init() {
 	serverCmd.Flags().IntVar(&serverConfig.LogSizeThreshold, "log-size-threshold", 1024, "the rotation threshold in KB")
 	serverCmd.Flags().IntVar(&serverConfig.LogKeepFiles, "log-keep-files", 2, "rolled files to keep")
 	serverCmd.Flags().BoolVar(&serverConfig.Force, "force", true, "force write")
-	_ = serverCmd.MarkFlagRequired("name")
-	_ = serverCmd.MarkFlagRequired("executable")
+	serverCmd.Flags().StringVar(&serverConfig.StopTimeout, "stop-timeout", "", "stop timeout like '15 sec'")
+	serverCmd.Flags().StringVar(&serverConfig.Runtime, "runtime", "", "language runtime(python|java|node), executable is then the script, module or jar")
+	serverCmd.Flags().StringVar(&serverConfig.Interpreter, "interpreter", "", "interpreter path (default: detected)")
+	serverCmd.Flags().StringVar(&serverConfig.Venv, "venv", "", "python virtualenv directory (default: .venv or venv in working directory)")
+	serverCmd.Flags().StringSliceVar(&serverConfig.JvmOptions, "jvm-options", []string{}, "jvm options")
+	serverCmd.Flags().StringVar(&serverConfig.Heap, "heap", "", "jvm heap size like 512m")
+	serverCmd.Flags().StringSliceVar(&serverConfig.OpenPorts, "open-port", []string{}, "inbound firewall port like 'tcp:9000' or 'udp:8000-8010'")
+	serverCmd.Flags().StringVar(&serverConfig.Manifest, "manifest", "", "service manifest file(json|yaml), flags override the manifest")
+	serverCmd.Flags().StringSliceVar(&serverConfig.Services, "service", []string{}, "manifest services to generate by name or id (default all), other flags override the selected services")
+	serverCmd.Flags().StringVar(&serverConfig.EnvProfile, "env-profile", "", "environment profile merged over the manifest")
+	serverCmd.Flags().BoolVar(&serverConfig.Print, "print", false, "print the resolved service definitions without writing")
+	serverCmd.Flags().BoolVarP(&serverConfig.Interactive, "interactive", "i", false, "prompt for the service definition")
 
 	// Boot Start ("Boot")
 	// Device driver started by the operating system loader. This value is valid only for driver services.
@@ -70,11 +95,33 @@ func init() {
 	// Service that can no longer be started.
 }
 
+var winserverCmd = &cobra.Command{
+	Use:   "winserver",
+	Short: "manage generated windows servers",
+	Long:  `manage generated windows servers`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Println(cmd.UsageString())
+		return nil
+	},
+}
+
 var serverCmd = &cobra.Command{
 	Use:   "winserver-gen",
 	Short: "generate exe file's windows server",
 	Long:  `generate exe file's windows server`,
 	Args: func(cmd *cobra.Command, args []string) error {
+		if serverConfig.Interactive && serverConfig.Manifest != "" {
+			return fmt.Errorf("interactive can't be used with manifest")
+		}
+		if serverConfig.Manifest != "" || serverConfig.Interactive {
+			return nil
+		}
+		if serverConfig.EnvProfile != "" {
+			return fmt.Errorf("env-profile requires manifest")
+		}
+		if len(serverConfig.Services) > 0 {
+			return fmt.Errorf("service requires manifest")
+		}
 		if serverConfig.Name == "" {
 			return fmt.Errorf("missing name")
 		}
@@ -87,35 +134,189 @@ var serverCmd = &cobra.Command{
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		s, err := winserver.NewServer(
-			winserver.WithSId(serverConfig.ID),
-			winserver.WithSName(serverConfig.Name),
-			winserver.WithSExecutable(serverConfig.Executable),
-			winserver.WithSDescription(serverConfig.Description),
-			winserver.WithSStartMode(serverConfig.StartMode),
-			winserver.WithSDepends(serverConfig.Depends),
-			winserver.WithSLogPath(serverConfig.LogPath),
-			winserver.WithSArguments(serverConfig.Arguments),
-			winserver.WithSStartArguments(serverConfig.StartArguments),
-			winserver.WithSStopExecutable(serverConfig.StopExecutable),
-			winserver.WithSStopArguments(serverConfig.StopArguments),
-			winserver.WithSEnv(serverConfig.Env),
-			winserver.WithSFailure(serverConfig.Failure),
-			winserver.WithSWorkingDirectory(serverConfig.WorkingDirectory),
-			winserver.WithSLogMode(serverConfig.LogMode),
-			winserver.WithSLogPattern(serverConfig.LogPattern),
-			winserver.WithSLogAutoRollAtTime(serverConfig.LogAutoRollAtTime),
-			winserver.WithSLogSizeThreshold(serverConfig.LogSizeThreshold),
-			winserver.WithSLogKeepFiles(serverConfig.LogKeepFiles),
-			winserver.WithSForce(serverConfig.Force),
-		)
-		if err != nil {
-			return err
+		var servers []*winserver.Server
+		var err error
+		if serverConfig.Interactive {
+			servers, err = interactiveServers(cmd)
+			if err != nil {
+				return err
+			}
+			if servers == nil {
+				fmt.Println("cancelled")
+				return nil
+			}
+			defer fmt.Printf("\nequivalent command:\n%s\n", equivalentCommandLine(cmd))
+		} else {
+			servers, err = resolveServers(cmd)
+			if err != nil {
+				return err
+			}
 		}
-		err = s.Generate()
-		if err != nil {
-			return err
+		if serverConfig.Print {
+			out, err := (&winserver.Manifest{Services: servers}).Marshal("")
+			if err != nil {
+				return err
+			}
+			fmt.Print(string(out))
+			return nil
+		}
+		for _, s := range servers {
+			if err := s.Generate(); err != nil {
+				return err
+			}
 		}
 		return nil
 	},
 }
+
+// resolveServers 返回要生成的服务定义。
+// 未指定清单时由命令行参数构造；指定清单时按 --service 选择服务并合并环境覆盖项，再应用显式传入的参数。
+// 清单中未设置的字段使用命令行参数的默认值。
+func resolveServers(cmd *cobra.Command) ([]*winserver.Server, error) {
+	if serverConfig.Manifest == "" {
+		s, err := winserver.NewServer(serverOptions(cmd, false)...)
+		if err != nil {
+			return nil, err
+		}
+		return []*winserver.Server{s}, nil
+	}
+
+	manifest, err := winserver.LoadManifest(serverConfig.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	defaults, err := winserver.NewServer(defaultOptions(cmd)...)
+	if err != nil {
+		return nil, err
+	}
+	for i, base := range manifest.Services {
+		if manifest.Services[i], err = winserver.MergeDefaults(defaults, base); err != nil {
+			return nil, err
+		}
+	}
+
+	var servers []*winserver.Server
+	if len(serverConfig.Services) > 0 {
+		for _, name := range serverConfig.Services {
+			s, err := manifest.Resolve(name, serverConfig.EnvProfile)
+			if err != nil {
+				return nil, err
+			}
+			servers = append(servers, s)
+		}
+	} else {
+		servers, err = manifest.ResolveAll(serverConfig.EnvProfile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(servers) > 1 {
+		for _, flag := range []string{"id", "name"} {
+			if cmd.Flags().Changed(flag) {
+				return nil, fmt.Errorf("--%s can only override a single service, select it with --service", flag)
+			}
+		}
+	}
+	for _, s := range servers {
+		if err := s.Apply(serverOptions(cmd, true)...); err != nil {
+			return nil, err
+		}
+	}
+	return servers, nil
+}
+
+type serverFlagOption struct {
+	flag   string
+	option winserver.Option
+}
+
+// serverFlagOptions 返回命令行参数与服务选项的对应关系，运行时参数见 runtimeOption
+func serverFlagOptions() []serverFlagOption {
+	return []serverFlagOption{
+		{"id", winserver.WithSId(serverConfig.ID)},
+		{"name", winserver.WithSName(serverConfig.Name)},
+		{"executable", winserver.WithSExecutable(serverConfig.Executable)},
+		{"description", winserver.WithSDescription(serverConfig.Description)},
+		{"start-mode", winserver.WithSStartMode(serverConfig.StartMode)},
+		{"depends", winserver.WithSDepends(serverConfig.Depends)},
+		{"log-path", winserver.WithSLogPath(serverConfig.LogPath)},
+		{"arguments", winserver.WithSArguments(serverConfig.Arguments)},
+		{"start-arguments", winserver.WithSStartArguments(serverConfig.StartArguments)},
+		{"stop-executable", winserver.WithSStopExecutable(serverConfig.StopExecutable)},
+		{"stop-arguments", winserver.WithSStopArguments(serverConfig.StopArguments)},
+		{"env", winserver.WithSEnv(serverConfig.Env)},
+		{"failure", winserver.WithSFailure(serverConfig.Failure)},
+		{"working-directory", winserver.WithSWorkingDirectory(serverConfig.WorkingDirectory)},
+		{"log-mode", winserver.WithSLogMode(serverConfig.LogMode)},
+		{"log-pattern", winserver.WithSLogPattern(serverConfig.LogPattern)},
+		{"log-auto-roll-at-time", winserver.WithSLogAutoRollAtTime(serverConfig.LogAutoRollAtTime)},
+		{"log-size-threshold", winserver.WithSLogSizeThreshold(serverConfig.LogSizeThreshold)},
+		{"log-keep-files", winserver.WithSLogKeepFiles(serverConfig.LogKeepFiles)},
+		{"stop-timeout", winserver.WithSStopTimeout(serverConfig.StopTimeout)},
+		{"open-port", winserver.WithSOpenPorts(serverConfig.OpenPorts)},
+	}
+}
+
+// defaultOptions 返回有默认值的参数 (如 log-path、log-mode) 对应的选项，用于补全清单中未设置的字段。
+// 显式传入的参数之后还会覆盖到服务上，这里直接使用参数的当前值。
+func defaultOptions(cmd *cobra.Command) []winserver.Option {
+	var opts []winserver.Option
+	for _, o := range serverFlagOptions() {
+		switch cmd.Flags().Lookup(o.flag).DefValue {
+		case "", "[]", "0", "false":
+			continue
+		}
+		opts = append(opts, o.option)
+	}
+	return opts
+}
+
+// serverOptions 将命令行参数转换为服务选项，onlyChanged 为 true 时只包含显式传入的参数
+func serverOptions(cmd *cobra.Command, onlyChanged bool) []winserver.Option {
+	var opts []winserver.Option
+	for _, o := range serverFlagOptions() {
+		if onlyChanged && !cmd.Flags().Changed(o.flag) {
+			continue
+		}
+		opts = append(opts, o.option)
+	}
+	return append(opts, runtimeOption(cmd, onlyChanged), winserver.WithSForce(serverConfig.Force))
+}
+
+// runtimeOption 返回运行时选项，onlyChanged 为 true 时只覆盖显式传入的参数，保留清单中的其他运行时配置。
+// 没有运行时时传入 --interpreter 等运行时参数会报错。
+func runtimeOption(cmd *cobra.Command, onlyChanged bool) winserver.Option {
+	return func(s *winserver.Server) error {
+		runtime := &winserver.Runtime{}
+		if onlyChanged && s.SRuntime != nil {
+			copied := *s.SRuntime
+			runtime = &copied
+		}
+		changed := func(flag string) bool {
+			return !onlyChanged || cmd.Flags().Changed(flag)
+		}
+		if changed("runtime") {
+			runtime.Name = serverConfig.Runtime
+		}
+		if changed("interpreter") {
+			runtime.Interpreter = serverConfig.Interpreter
+		}
+		if changed("venv") {
+			runtime.Venv = serverConfig.Venv
+		}
+		if changed("jvm-options") {
+			runtime.JvmOptions = serverConfig.JvmOptions
+		}
+		if changed("heap") {
+			runtime.Heap = serverConfig.Heap
+		}
+		if runtime.Name == "" {
+			for _, flag := range []string{"interpreter", "venv", "jvm-options", "heap"} {
+				if cmd.Flags().Changed(flag) {
+					return fmt.Errorf("%s: --%s requires --runtime", s.SName, flag)
+				}
+			}
+		}
+		return winserver.WithSRuntime(runtime)(s)
+	}
+}
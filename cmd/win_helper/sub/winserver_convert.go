@@ -0,0 +1,87 @@
+package sub
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"win_helper/pkg/winserver"
+)
+
+type WinServerConvertConfig struct {
+	From    string
+	Format  string
+	OutFile string
+	Force   bool
+}
+
+var winserverConvertConfig = WinServerConvertConfig{}
+
+func init() {
+	winserverCmd.AddCommand(winserverConvertCmd)
+
+	winserverConvertCmd.Flags().StringVar(&winserverConvertConfig.From, "from", "", "source format(compose|procfile)")
+	winserverConvertCmd.Flags().StringVar(&winserverConvertConfig.Format, "format", "manifest", "output format(manifest|xml)")
+	winserverConvertCmd.Flags().StringVarP(&winserverConvertConfig.OutFile, "out", "o", "", "manifest file (default: stdout) or xml output directory (default: ./)")
+	winserverConvertCmd.Flags().BoolVar(&winserverConvertConfig.Force, "force", false, "force write")
+	_ = winserverConvertCmd.MarkFlagRequired("from")
+}
+
+var winserverConvertCmd = &cobra.Command{
+	Use:   "convert <file>",
+	Short: "convert docker-compose services or Procfile into service definitions",
+	Long:  `convert docker-compose services or Procfile into service definitions, write a manifest or WinSW xml files`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("读取文件失败: %v", err)
+		}
+		result, err := winserver.Convert(winserverConvertConfig.From, data)
+		if err != nil {
+			return err
+		}
+
+		switch winserverConvertConfig.Format {
+		case "manifest":
+			manifest := &winserver.Manifest{Services: result.Services}
+			if winserverConvertConfig.OutFile == "" {
+				out, err := manifest.Marshal("")
+				if err != nil {
+					return err
+				}
+				fmt.Print(string(out))
+			} else if err := manifest.Save(winserverConvertConfig.OutFile, winserverConvertConfig.Force); err != nil {
+				return err
+			}
+		case "xml":
+			outDir := winserverConvertConfig.OutFile
+			if outDir == "" {
+				outDir = "./"
+			}
+			if err := os.MkdirAll(outDir, 0o755); err != nil {
+				return fmt.Errorf("创建目录失败: %v", err)
+			}
+			for _, s := range result.Services {
+				err := s.Apply(
+					winserver.WithBasePath(outDir),
+					winserver.WithSForce(winserverConvertConfig.Force),
+				)
+				if err != nil {
+					return err
+				}
+				if err := s.GenerateServerXML(); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("invalid output format: %s", winserverConvertConfig.Format)
+		}
+
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+		}
+		return nil
+	},
+}
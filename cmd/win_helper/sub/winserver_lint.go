@@ -0,0 +1,73 @@
+package sub
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"win_helper/pkg/winserver"
+)
+
+type WinServerLintConfig struct {
+	Manifest     string
+	EnvProfile   string
+	PortPatterns []string
+}
+
+var winserverLintConfig = WinServerLintConfig{}
+
+func init() {
+	winserverCmd.AddCommand(winserverLintCmd)
+
+	winserverLintCmd.Flags().StringVar(&winserverLintConfig.Manifest, "manifest", "", "service manifest file(json|yaml)")
+	winserverLintCmd.Flags().StringVar(&winserverLintConfig.EnvProfile, "env-profile", "", "environment profile merged over the manifest")
+	winserverLintCmd.Flags().StringArrayVar(&winserverLintConfig.PortPatterns, "port-pattern", []string{}, "regexp to extract ports from arguments and env, the first group is the port (default: built-in patterns)")
+}
+
+var winserverLintCmd = &cobra.Command{
+	Use:   "lint [xml file or dir]...",
+	Short: "check a service set for conflicts",
+	Long:  `check generated xml files or a manifest for duplicate ids/names, shared log paths, missing executables and port conflicts`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if winserverLintConfig.Manifest == "" && len(args) == 0 {
+			return fmt.Errorf("missing xml files or manifest")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		linter, err := winserver.NewLinter(winserverLintConfig.PortPatterns)
+		if err != nil {
+			return err
+		}
+		targets, err := winserver.LoadLintTargets(args)
+		if err != nil {
+			return err
+		}
+		if winserverLintConfig.Manifest != "" {
+			manifest, err := winserver.LoadManifest(winserverLintConfig.Manifest)
+			if err != nil {
+				return err
+			}
+			manifestTargets, err := winserver.ManifestLintTargets(manifest, winserverLintConfig.EnvProfile, filepath.Dir(winserverLintConfig.Manifest))
+			if err != nil {
+				return err
+			}
+			targets = append(targets, manifestTargets...)
+		}
+
+		issues := linter.Lint(targets)
+		errors := 0
+		for _, issue := range issues {
+			fmt.Println(issue.String())
+			if issue.Level == winserver.LintError {
+				errors++
+			}
+		}
+		fmt.Printf("checked %d services, %d issues\n", len(targets), len(issues))
+		if errors > 0 {
+			return fmt.Errorf("%d error(s) found", errors)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,57 @@
+package sub
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"win_helper/pkg/winserver"
+)
+
+type WinServerSchemaConfig struct {
+	Kind    string
+	OutFile string
+}
+
+var winserverSchemaConfig = WinServerSchemaConfig{}
+
+func init() {
+	winserverCmd.AddCommand(winserverSchemaCmd)
+
+	winserverSchemaCmd.Flags().StringVar(&winserverSchemaConfig.Kind, "kind", "server", "schema kind(server|xml)")
+	winserverSchemaCmd.Flags().StringVarP(&winserverSchemaConfig.OutFile, "out", "o", "", "output file (default: stdout)")
+}
+
+var winserverSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "export json schema of service definitions",
+	Long:  `export json schema of service definitions, used by editors for autocompletion and validation`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var schema *winserver.Schema
+		var err error
+		switch winserverSchemaConfig.Kind {
+		case "server":
+			schema, err = winserver.ServerSchema()
+		case "xml":
+			schema, err = winserver.ServerXMLSchema()
+		default:
+			return fmt.Errorf("invalid schema kind: %s", winserverSchemaConfig.Kind)
+		}
+		if err != nil {
+			return err
+		}
+		out, err := schema.ToJson()
+		if err != nil {
+			return err
+		}
+		if winserverSchemaConfig.OutFile == "" {
+			fmt.Println(out)
+			return nil
+		}
+		if err := os.WriteFile(winserverSchemaConfig.OutFile, []byte(out+"\n"), 0o644); err != nil {
+			return fmt.Errorf("写入文件失败。%v", err)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,42 @@
+package sub
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"win_helper/pkg/winserver"
+)
+
+type WinServerUpgradeConfig struct {
+	DryRun bool
+}
+
+var winserverUpgradeConfig = WinServerUpgradeConfig{}
+
+func init() {
+	winserverCmd.AddCommand(winserverUpgradeCmd)
+
+	winserverUpgradeCmd.Flags().BoolVar(&winserverUpgradeConfig.DryRun, "dry-run", false, "only report, do not replace files")
+}
+
+var winserverUpgradeCmd = &cobra.Command{
+	Use:   "upgrade <dir>",
+	Short: "upgrade WinSW wrappers under a directory tree",
+	Long:  `find every *.exe with a sibling xml under dir and replace it with the embedded WinSW, xml files are left untouched`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		report, err := winserver.UpgradeWrappers(args[0], winserverUpgradeConfig.DryRun)
+		if err != nil {
+			return err
+		}
+		if report.DryRun {
+			fmt.Println("dry run, no file changed")
+		}
+		fmt.Print(report.String())
+		if failed := report.Count(winserver.UpgradeStatusFailed); failed > 0 {
+			return fmt.Errorf("%d wrapper(s) failed to upgrade", failed)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,194 @@
+package sub
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"win_helper/pkg/winserver"
+)
+
+// wizardSkipFlags 不输出到等价命令行的参数
+var wizardSkipFlags = map[string]bool{
+	"interactive": true,
+	"print":       true,
+	"manifest":    true,
+	"env-profile": true,
+}
+
+func validateRequired(s string) error {
+	if s == "" {
+		return fmt.Errorf("required")
+	}
+	return nil
+}
+
+func validateServiceName(s string) error {
+	if err := validateRequired(s); err != nil {
+		return err
+	}
+	if strings.ContainsAny(s, `\/:*?"<>|`) {
+		return fmt.Errorf(`must not contain \/:*?"<>|`)
+	}
+	return nil
+}
+
+func validateFailure(s string) error {
+	_, err := winserver.ParseFailure(s)
+	return err
+}
+
+func validateOpenPort(s string) error {
+	_, err := winserver.ParseOpenPort(s)
+	return err
+}
+
+// runServerWizard 通过问答填写 serverConfig，展示最终的 XML 并确认。
+// 返回 false 表示用户取消。
+func runServerWizard(cmd *cobra.Command, p *Prompter) (bool, error) {
+	var err error
+	c := &serverConfig
+	if c.Name, err = p.Ask("Service name", c.Name, validateServiceName); err != nil {
+		return false, err
+	}
+	id := c.ID
+	if id == "" {
+		id = c.Name
+	}
+	if c.ID, err = p.Ask("Service id", id, validateServiceName); err != nil {
+		return false, err
+	}
+	if c.Executable, err = p.Ask("Executable", c.Executable, validateRequired); err != nil {
+		return false, err
+	}
+	description := c.Description
+	if description == "" {
+		description = c.Name
+	}
+	if c.Description, err = p.Ask("Description", description, nil); err != nil {
+		return false, err
+	}
+	if c.Arguments, err = p.Ask("Arguments", c.Arguments, nil); err != nil {
+		return false, err
+	}
+	if c.WorkingDirectory, err = p.Ask("Working directory", c.WorkingDirectory, nil); err != nil {
+		return false, err
+	}
+	if c.StartMode, err = p.Choose("Start mode", winserver.StartModes, c.StartMode); err != nil {
+		return false, err
+	}
+
+	if c.LogMode, err = p.Choose("Log mode", winserver.LogModes, c.LogMode); err != nil {
+		return false, err
+	}
+	if c.LogMode != "none" {
+		if c.LogPath, err = p.Ask("Log path", c.LogPath, nil); err != nil {
+			return false, err
+		}
+	}
+	switch c.LogMode {
+	case "roll-by-size", "roll-by-size-time":
+		if c.LogSizeThreshold, err = p.AskInt("Log size threshold (KB)", c.LogSizeThreshold); err != nil {
+			return false, err
+		}
+		if c.LogKeepFiles, err = p.AskInt("Log rolled files to keep", c.LogKeepFiles); err != nil {
+			return false, err
+		}
+	}
+	switch c.LogMode {
+	case "roll-by-time", "roll-by-size-time":
+		pattern := c.LogPattern
+		if pattern == "" {
+			pattern = "yyyyMMdd"
+		}
+		if c.LogPattern, err = p.Ask("Log pattern", pattern, validateRequired); err != nil {
+			return false, err
+		}
+	}
+	if c.LogMode == "roll-by-size-time" {
+		if c.LogAutoRollAtTime, err = p.Ask("Log auto roll at time (HH:mm:ss)", c.LogAutoRollAtTime, nil); err != nil {
+			return false, err
+		}
+	}
+
+	if c.Env, err = p.AskList("Environment variables (KEY=VALUE, comma separated)", c.Env, func(s string) error {
+		if !strings.Contains(s, "=") {
+			return fmt.Errorf("%s is not KEY=VALUE", s)
+		}
+		return nil
+	}); err != nil {
+		return false, err
+	}
+	if c.Depends, err = p.AskList("Depends on services (comma separated)", c.Depends, nil); err != nil {
+		return false, err
+	}
+	if c.Failure, err = p.Ask("Failure policy (e.g. restart:10 sec,reboot)", c.Failure, validateFailure); err != nil {
+		return false, err
+	}
+	if c.OpenPorts, err = p.AskList("Open ports (e.g. tcp:9000, comma separated)", c.OpenPorts, validateOpenPort); err != nil {
+		return false, err
+	}
+
+	s, err := winserver.NewServer(serverOptions(cmd, false)...)
+	if err != nil {
+		return false, err
+	}
+	serverXML, err := s.BuildServerXML()
+	if err != nil {
+		return false, err
+	}
+	out, err := serverXML.ToXML()
+	if err != nil {
+		return false, err
+	}
+	fmt.Fprintf(p.out, "\n%s\n\n", out)
+	return p.Confirm("Write files?", true)
+}
+
+// equivalentCommandLine 返回与当前参数值等价的非交互命令行
+func equivalentCommandLine(cmd *cobra.Command) string {
+	args := []string{cmd.CommandPath()}
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if wizardSkipFlags[f.Name] || f.Value.String() == f.DefValue {
+			return
+		}
+		switch f.Value.Type() {
+		case "stringSlice", "stringArray":
+			values, _ := cmd.Flags().GetStringSlice(f.Name)
+			if f.Value.Type() == "stringArray" {
+				values, _ = cmd.Flags().GetStringArray(f.Name)
+			}
+			for _, v := range values {
+				args = append(args, "--"+f.Name, quoteArg(v))
+			}
+		case "bool":
+			args = append(args, fmt.Sprintf("--%s=%s", f.Name, f.Value.String()))
+		default:
+			args = append(args, "--"+f.Name, quoteArg(f.Value.String()))
+		}
+	})
+	return strings.Join(args, " ")
+}
+
+func quoteArg(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t&|<>^\"") {
+		return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+	}
+	return s
+}
+
+// interactiveServers 交互式构造服务定义，用户取消时返回 nil
+func interactiveServers(cmd *cobra.Command) ([]*winserver.Server, error) {
+	ok, err := runServerWizard(cmd, NewPrompter(os.Stdin, os.Stdout))
+	if err != nil || !ok {
+		return nil, err
+	}
+	s, err := winserver.NewServer(serverOptions(cmd, false)...)
+	if err != nil {
+		return nil, err
+	}
+	return []*winserver.Server{s}, nil
+}
@@ -6,11 +6,17 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
+	"win_helper/pkg/util/versionUtils/manager"
 )
 
 func GetAppCurrentVersion(dir string) (string, error) {
+	return GetAppVersion(filepath.Join(dir, "VERSION"))
+}
+
+// GetAppVersion 读取版本号文件，去掉首尾空白
+func GetAppVersion(fileName string) (string, error) {
 	var err error
-	fileName := filepath.Join(dir, "VERSION")
 	if !fsutil.FileExist(fileName) {
 		return "", fmt.Errorf("%v does not exist", fileName)
 	}
@@ -18,5 +24,10 @@ func GetAppCurrentVersion(dir string) (string, error) {
 	if err != nil {
 		log.Fatalf("failed to read file: %v", err)
 	}
-	return string(data), nil
+	return strings.TrimSpace(string(data)), nil
+}
+
+// SaveAppVersion 写入版本号文件，update-app 和 obr release 的 bump 步骤使用同样的格式
+func SaveAppVersion(version string, fileName string) error {
+	return manager.NewVersionManager(manager.WithVersion(version)).SaveAs(fileName, true)
 }
@@ -0,0 +1,147 @@
+package obr
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"win_helper/pkg/util/versionUtils"
+	"win_helper/pkg/util/versionUtils/manager"
+)
+
+// BumpAuto 根据提交自动决定升级级别的操作符
+const BumpAuto = "auto"
+
+// literalVersionPattern 直接指定的版本号，如 2.0.0、2.0.0-rc.1
+var literalVersionPattern = regexp.MustCompile(`^\d+\.\d+\.\d+(?:[-+][0-9A-Za-z.+-]+)?$`)
+
+// BumpLevel 版本升级级别
+type BumpLevel int
+
+const (
+	BumpNone BumpLevel = iota
+	BumpPatch
+	BumpMinor
+	BumpMajor
+)
+
+func (l BumpLevel) String() string {
+	switch l {
+	case BumpPatch:
+		return "patch"
+	case BumpMinor:
+		return "minor"
+	case BumpMajor:
+		return "major"
+	}
+	return "none"
+}
+
+// BumpDecision 自动升级的结果，Reasons 为决定升级级别的提交
+type BumpDecision struct {
+	Since   string
+	Level   BumpLevel
+	Commits []*ConventionalCommit
+	Reasons []*ConventionalCommit
+}
+
+// commitBumpLevel 单个提交对应的升级级别: BREAKING CHANGE 为 major，feat 为 minor，fix/perf 为 patch
+func commitBumpLevel(c *ConventionalCommit) BumpLevel {
+	switch {
+	case c.Breaking:
+		return BumpMajor
+	case c.Type == "feat":
+		return BumpMinor
+	case c.Type == "fix", c.Type == "perf":
+		return BumpPatch
+	}
+	return BumpNone
+}
+
+// DecideBump 根据提交决定升级级别
+func DecideBump(since string, commits []*ConventionalCommit) *BumpDecision {
+	d := &BumpDecision{Since: since, Commits: commits}
+	for _, c := range commits {
+		level := commitBumpLevel(c)
+		switch {
+		case level == BumpNone || level < d.Level:
+		case level > d.Level:
+			d.Level = level
+			d.Reasons = []*ConventionalCommit{c}
+		default:
+			d.Reasons = append(d.Reasons, c)
+		}
+	}
+	return d
+}
+
+// AutoBump 检查上一个标签之后的提交并决定升级级别，只考虑 tagTemplate 生成的标签
+func AutoBump(git Git, tagTemplate string) (*BumpDecision, error) {
+	since, err := git.LastTag("HEAD", TagPatterns(tagTemplate)...)
+	if err != nil {
+		return nil, err
+	}
+	commits, err := git.Log(since, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return DecideBump(since, ParseConventionalCommits(commits)), nil
+}
+
+// Next 返回升级后的版本号，低位版本号归零
+func (d *BumpDecision) Next(version string) (string, error) {
+	major, minor, patch := versionUtils.Proto(version), versionUtils.Major(version), versionUtils.Minor(version)
+	switch d.Level {
+	case BumpMajor:
+		return fmt.Sprintf("%d.0.0", major+1), nil
+	case BumpMinor:
+		return fmt.Sprintf("%d.%d.0", major, minor+1), nil
+	case BumpPatch:
+		return fmt.Sprintf("%d.%d.%d", major, minor, patch+1), nil
+	}
+	return "", fmt.Errorf("nothing releasable since %s: %d commit(s), no feat, fix or BREAKING CHANGE", d.sinceName(), len(d.Commits))
+}
+
+func (d *BumpDecision) sinceName() string {
+	if d.Since == "" {
+		return "the first commit"
+	}
+	return d.Since
+}
+
+// String 说明升级级别和决定升级的提交
+func (d *BumpDecision) String() string {
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "%d commit(s) since %s, bump %s", len(d.Commits), d.sinceName(), d.Level)
+	for _, c := range d.Reasons {
+		_, _ = fmt.Fprintf(&b, "\n  %s %s", c.ShortHash(), c.Subject)
+		if c.Breaking && !strings.Contains(c.Subject, "!:") {
+			b.WriteString(" (BREAKING CHANGE)")
+		}
+	}
+	return b.String()
+}
+
+// NextVersion 按操作符计算新版本号，auto 根据上一个匹配 tagTemplate 的标签之后的提交决定升级级别并将原因输出到 out，
+// op 为 2.0.0 这样的版本号时直接使用该版本
+func NextVersion(git Git, currentVersion string, op string, tagTemplate string, out io.Writer) (string, error) {
+	if literalVersionPattern.MatchString(op) {
+		return op, nil
+	}
+	if op == BumpAuto {
+		decision, err := AutoBump(git, tagTemplate)
+		if err != nil {
+			return "", err
+		}
+		if out != nil {
+			_, _ = fmt.Fprintln(out, decision)
+		}
+		return decision.Next(currentVersion)
+	}
+	versionManager := manager.NewVersionManager(manager.WithVersion(currentVersion))
+	if err := versionManager.SetVersion(op); err != nil {
+		return "", fmt.Errorf("error updating version: %v", err)
+	}
+	return versionManager.GetVersion(), nil
+}
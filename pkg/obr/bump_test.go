@@ -0,0 +1,119 @@
+package obr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNextVersion(t *testing.T) {
+	tests := []struct {
+		current string
+		op      string
+		want    string
+	}{
+		{"1.2.3", "=", "1.2.3"},
+		{"1.2.3", "2.0.0", "2.0.0"},
+		{"1.2.3", "1.0.0", "1.0.0"},
+		{"1.2.3", "2.0.0-rc.1", "2.0.0-rc.1"},
+	}
+	for _, tt := range tests {
+		got, err := NextVersion(nil, tt.current, tt.op, "", nil)
+		if err != nil {
+			t.Errorf("NextVersion(%q, %q) error: %v", tt.current, tt.op, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NextVersion(%q, %q) = %q, want %q", tt.current, tt.op, got, tt.want)
+		}
+	}
+	if _, err := NextVersion(nil, "1.2.3", "2.0", "", nil); err == nil {
+		t.Error("NextVersion(2.0) should fail")
+	}
+}
+
+func TestParseConventionalCommit(t *testing.T) {
+	tests := []struct {
+		subject      string
+		body         string
+		typ          string
+		scope        string
+		breaking     bool
+		conventional bool
+	}{
+		{"feat: add login", "", "feat", "", false, true},
+		{"fix(api): handle nil", "", "fix", "api", false, true},
+		{"feat!: drop v1 api", "", "feat", "", true, true},
+		{"refactor(core)!: rename config", "", "refactor", "core", true, true},
+		{"feat: new flags", "details\n\nBREAKING CHANGE: --old is removed", "feat", "", true, true},
+		{"fix: typo", "BREAKING-CHANGE: renamed", "fix", "", true, true},
+		{"fix: mention", "not a BREAKING CHANGE: footer", "fix", "", false, true},
+		{"Update README", "", "", "", false, false},
+		{"Merge branch 'main'", "", "", "", false, false},
+	}
+	for _, tt := range tests {
+		c := ParseConventionalCommit(&Commit{Subject: tt.subject, Body: tt.body})
+		if c.Type != tt.typ || c.Scope != tt.scope || c.Breaking != tt.breaking || c.Conventional != tt.conventional {
+			t.Errorf("ParseConventionalCommit(%q) = type %q scope %q breaking %v conventional %v",
+				tt.subject, c.Type, c.Scope, c.Breaking, c.Conventional)
+		}
+	}
+}
+
+func TestDecideBump(t *testing.T) {
+	tests := []struct {
+		name     string
+		subjects []string
+		level    BumpLevel
+		reasons  int
+		next     string
+	}{
+		{"no commits", nil, BumpNone, 0, ""},
+		{"non-conventional", []string{"Update README", "wip"}, BumpNone, 0, ""},
+		{"docs only", []string{"docs: usage", "chore: deps"}, BumpNone, 0, ""},
+		{"fix", []string{"fix: a", "docs: b", "perf: c"}, BumpPatch, 2, "1.2.4"},
+		{"feat over fix", []string{"fix: a", "feat: b", "feat(ui): c"}, BumpMinor, 2, "1.3.0"},
+		{"breaking subject", []string{"feat: a", "fix!: b"}, BumpMajor, 1, "2.0.0"},
+		{"breaking footer", []string{"feat: a", "chore: b\n\nBREAKING CHANGE: c"}, BumpMajor, 1, "2.0.0"},
+	}
+	for _, tt := range tests {
+		var commits []*Commit
+		for _, s := range tt.subjects {
+			subject, body, _ := strings.Cut(s, "\n\n")
+			commits = append(commits, &Commit{Hash: "0123456789", Subject: subject, Body: body})
+		}
+		d := DecideBump("v1.2.3", ParseConventionalCommits(commits))
+		if d.Level != tt.level || len(d.Reasons) != tt.reasons {
+			t.Errorf("%s: level %s with %d reason(s), want %s with %d", tt.name, d.Level, len(d.Reasons), tt.level, tt.reasons)
+		}
+		next, err := d.Next("1.2.3")
+		switch {
+		case tt.next == "" && err == nil:
+			t.Errorf("%s: Next() = %q, want an error", tt.name, next)
+		case tt.next != "" && next != tt.next:
+			t.Errorf("%s: Next() = %q, %v, want %q", tt.name, next, err, tt.next)
+		}
+	}
+}
+
+func TestAutoBumpUsesTemplateTags(t *testing.T) {
+	git := NewFakeGit()
+	git.AddCommit("feat: first")
+	if err := git.Tag("v1.0.0", "release"); err != nil {
+		t.Fatal(err)
+	}
+	git.AddCommit("fix: bug")
+	if err := git.Tag("deploy-prod", "deploy"); err != nil {
+		t.Fatal(err)
+	}
+	git.AddCommit("docs: usage")
+
+	got, err := NextVersion(git, "1.0.0", BumpAuto, "v{{ version }}", nil)
+	if err != nil || got != "1.0.1" {
+		t.Errorf("NextVersion(auto) = %q, %v, want 1.0.1 counted from v1.0.0", got, err)
+	}
+	// 模板为空时同样忽略 deploy-prod
+	d, err := AutoBump(git, "")
+	if err != nil || d.Since != "v1.0.0" || len(d.Commits) != 2 {
+		t.Errorf("AutoBump() = %+v, %v", d, err)
+	}
+}
@@ -0,0 +1,183 @@
+package obr
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+	"github.com/gookit/goutil/fsutil"
+
+	"win_helper/pkg/util/fileutil"
+	"win_helper/templates"
+)
+
+// ChangelogFile 默认的更新日志文件
+const ChangelogFile = "CHANGELOG.md"
+
+const changelogHeader = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/).
+`
+
+// changelogSections 提交类型对应的 Keep a Changelog 分组，按顺序输出
+var changelogSections = []struct {
+	Title string
+	Types []string
+}{
+	{"Added", []string{"feat"}},
+	{"Changed", []string{"perf", "refactor"}},
+	{"Deprecated", []string{"deprecate", "deprecated"}},
+	{"Removed", []string{"remove", "revert"}},
+	{"Fixed", []string{"fix"}},
+	{"Security", []string{"security", "sec"}},
+}
+
+// ChangelogSection 一个分组
+type ChangelogSection struct {
+	Title   string
+	Entries []*ConventionalCommit
+}
+
+// Changelog 一个版本的更新日志
+type Changelog struct {
+	Version  string
+	Date     string
+	Breaking []*ConventionalCommit
+	Sections []*ChangelogSection
+}
+
+// NewChangelog 按提交类型分组，其他类型 (docs, chore 等) 和不符合规范的提交只有 includeOther 时输出到 Other
+func NewChangelog(version, date string, commits []*ConventionalCommit, includeOther bool) *Changelog {
+	c := &Changelog{Version: version, Date: date}
+	grouped := map[string][]*ConventionalCommit{}
+	var other []*ConventionalCommit
+	for _, commit := range commits {
+		if commit.Breaking {
+			c.Breaking = append(c.Breaking, commit)
+		}
+		title := changelogSectionTitle(commit.Type)
+		if title == "" {
+			if includeOther {
+				other = append(other, commit)
+			}
+			continue
+		}
+		grouped[title] = append(grouped[title], commit)
+	}
+	for _, section := range changelogSections {
+		if entries := grouped[section.Title]; len(entries) > 0 {
+			c.Sections = append(c.Sections, &ChangelogSection{Title: section.Title, Entries: entries})
+		}
+	}
+	if len(other) > 0 {
+		c.Sections = append(c.Sections, &ChangelogSection{Title: "Other", Entries: other})
+	}
+	return c
+}
+
+func changelogSectionTitle(commitType string) string {
+	for _, section := range changelogSections {
+		for _, t := range section.Types {
+			if t == commitType {
+				return section.Title
+			}
+		}
+	}
+	return ""
+}
+
+// Render 使用模板生成 markdown
+func (c *Changelog) Render() (string, error) {
+	template, err := templates.GetTemplateByName("obr/CHANGELOG.md.tpl")
+	if err != nil {
+		return "", err
+	}
+	tpl, err := pongo2.FromBytes(template)
+	if err != nil {
+		return "", err
+	}
+	out, err := tpl.Execute(pongo2.Context{"changelog": c})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out) + "\n", nil
+}
+
+// PrependChangelog 将内容插入到更新日志中第一个版本之前，文件不存在时创建。
+// 已有同一版本的段落 (## [version]) 时替换该段落，重复生成不会产生重复的版本
+func PrependChangelog(filename string, content string) error {
+	if !fsutil.FileExist(filename) {
+		return fileutil.WriteFileAtomic(filename, []byte(changelogHeader+"\n"+content), 0o644)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("读取文件失败: %v", err)
+	}
+	text, enc, err := fileutil.DecodeText(data)
+	if err != nil {
+		return err
+	}
+	existing := string(text)
+	eol := "\n"
+	if strings.Contains(existing, "\r\n") {
+		eol = "\r\n"
+		content = strings.ReplaceAll(content, "\n", eol)
+	}
+
+	// 插入到第一个二级标题之前，没有二级标题时追加到末尾
+	start, end := len(existing), len(existing)
+	if heading := changelogHeading(content); heading != "" {
+		if i := changelogSection(existing, eol, heading); i >= 0 {
+			start = i
+			end = len(existing)
+			if j := changelogSection(existing[i+len(eol):], eol, "## "); j >= 0 {
+				end = i + len(eol) + j
+			}
+		}
+	}
+	if start == len(existing) {
+		if i := changelogSection(existing, eol, "## "); i >= 0 {
+			start, end = i, i
+		}
+	}
+	head := existing[:start]
+	if head != "" && !strings.HasSuffix(head, eol+eol) {
+		head = strings.TrimRight(head, "\r\n") + eol + eol
+	}
+	tail := existing[end:]
+	if tail != "" {
+		content += eol
+	}
+
+	out, err := fileutil.EncodeText([]byte(head+content+tail), enc, fileutil.HasBOM(data))
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteFileAtomic(filename, out, 0o644)
+}
+
+// changelogHeading 返回内容中版本标题的开头，如 "## [1.2.0]"
+func changelogHeading(content string) string {
+	line, _, _ := strings.Cut(content, "\n")
+	if !strings.HasPrefix(line, "## [") {
+		return ""
+	}
+	if i := strings.Index(line, "]"); i >= 0 {
+		return line[:i+1]
+	}
+	return ""
+}
+
+// changelogSection 返回以 prefix 开头的第一行的位置，没有时返回 -1
+func changelogSection(text string, eol string, prefix string) int {
+	if strings.HasPrefix(text, prefix) {
+		return 0
+	}
+	if i := strings.Index(text, eol+prefix); i >= 0 {
+		return i + len(eol)
+	}
+	return -1
+}
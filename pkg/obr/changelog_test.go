@@ -0,0 +1,48 @@
+package obr
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrependChangelogReplacesSameVersion(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), ChangelogFile)
+	steps := []string{
+		"## [1.0.0] - 2026-01-01\n\n### Added\n\n- first (aaaaaaa)\n",
+		"## [1.1.0] - 2026-02-01\n\n### Fixed\n\n- bug (bbbbbbb)\n",
+		"## [1.1.0] - 2026-02-02\n\n### Fixed\n\n- bug (bbbbbbb)\n- another (ccccccc)\n",
+	}
+	for _, content := range steps {
+		if err := PrependChangelog(filename, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := changelogHeader + "\n" + steps[2] + "\n" + steps[0]
+	if got := readTestFile(t, filename); got != want {
+		t.Fatalf("changelog =\n%s\nwant\n%s", got, want)
+	}
+
+	// 替换最早的版本
+	if err := PrependChangelog(filename, strings.Replace(steps[0], "first", "initial", 1)); err != nil {
+		t.Fatal(err)
+	}
+	got := readTestFile(t, filename)
+	if strings.Count(got, "## [1.0.0]") != 1 || !strings.HasSuffix(got, "- initial (aaaaaaa)\n") || strings.Count(got, "## [1.1.0]") != 1 {
+		t.Fatalf("changelog =\n%s", got)
+	}
+}
+
+func TestPrependChangelogCRLF(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), ChangelogFile)
+	writeTestFile(t, filename, "# Changelog\r\n\r\n## [1.0.0]\r\n\r\n- first\r\n")
+	for i := 0; i < 2; i++ {
+		if err := PrependChangelog(filename, "## [1.1.0]\n\n- second\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := "# Changelog\r\n\r\n## [1.1.0]\r\n\r\n- second\r\n\r\n## [1.0.0]\r\n\r\n- first\r\n"
+	if got := readTestFile(t, filename); got != want {
+		t.Fatalf("changelog = %q, want %q", got, want)
+	}
+}
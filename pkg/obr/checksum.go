@@ -0,0 +1,299 @@
+package obr
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gookit/goutil/fsutil"
+
+	"win_helper/pkg/util/fileutil"
+)
+
+// 校验文件的默认名称
+const (
+	ChecksumFile = "SHA256SUMS"
+	ManifestFile = "manifest.json"
+)
+
+// Artifact 发布目录中的文件
+type Artifact struct {
+	File    string `json:"file"`
+	Size    int64  `json:"size"`
+	SHA256  string `json:"sha256"`
+	Version string `json:"version,omitempty"`
+	// Installer 文件名符合 OutputBaseFilename
+	Installer bool `json:"installer,omitempty"`
+}
+
+// ArtifactManifest 发布目录的清单
+type ArtifactManifest struct {
+	Version   string      `json:"version,omitempty"`
+	Generated time.Time   `json:"generated"`
+	Files     []*Artifact `json:"files"`
+}
+
+// ArtifactNaming 按 ISS 的 OutputBaseFilename 识别安装包及其版本号
+type ArtifactNaming struct {
+	// Current 当前版本安装包的文件名 (不含扩展名)
+	Current string
+	Version string
+	pattern *regexp.Regexp
+}
+
+// ISSNaming 读取 OutputBaseFilename，其中的版本号替换为通配，用于识别各版本的安装包
+func ISSNaming(f *ISSFile, versionKey string) *ArtifactNaming {
+	n := &ArtifactNaming{Current: f.SetupValue("OutputBaseFilename", "mysetup")}
+	if v, err := f.Get(versionKey); err == nil {
+		n.Version = v.Expanded
+	}
+	if n.Version != "" {
+		if i := strings.LastIndex(n.Current, n.Version); i >= 0 {
+			n.pattern = regexp.MustCompile(`(?i)^` + regexp.QuoteMeta(n.Current[:i]) + `(.+?)` + regexp.QuoteMeta(n.Current[i+len(n.Version):]) + `\.exe$`)
+		}
+	}
+	return n
+}
+
+// Match 判断文件是否为安装包，返回文件名中的版本号
+func (n *ArtifactNaming) Match(name string) (bool, string) {
+	base := filepath.Base(name)
+	if strings.EqualFold(base, n.Current+".exe") {
+		return true, n.Version
+	}
+	if n.pattern != nil {
+		if m := n.pattern.FindStringSubmatch(base); m != nil {
+			return true, m[1]
+		}
+	}
+	return false, ""
+}
+
+// InstallerName 当前版本安装包的文件名
+func (n *ArtifactNaming) InstallerName() string {
+	return n.Current + ".exe"
+}
+
+// fileSHA256 计算文件的 sha256
+func fileSHA256(filename string) (string, int64, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, file)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// ScanArtifacts 计算目录中所有文件的 sha256，skip 中的文件 (如校验文件本身) 不计算。
+// naming 不为空时只有安装包使用文件名中的版本号，其他文件使用 version。
+func ScanArtifacts(dir string, naming *ArtifactNaming, version string, skip ...string) ([]*Artifact, error) {
+	var artifacts []*Artifact
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		for _, s := range skip {
+			if strings.EqualFold(rel, s) {
+				return nil
+			}
+		}
+		hash, size, err := fileSHA256(path)
+		if err != nil {
+			return err
+		}
+		a := &Artifact{File: rel, Size: size, SHA256: hash, Version: version}
+		if naming != nil {
+			if ok, v := naming.Match(rel); ok {
+				a.Installer = true
+				if v != "" {
+					a.Version = v
+				}
+			}
+		}
+		artifacts = append(artifacts, a)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("扫描目录 %s 失败: %v", dir, err)
+	}
+	sort.Slice(artifacts, func(i, j int) bool { return artifacts[i].File < artifacts[j].File })
+	return artifacts, nil
+}
+
+// NewArtifactManifest 扫描目录生成清单，installerOnly 时只包含 naming 识别的安装包
+func NewArtifactManifest(dir string, naming *ArtifactNaming, version string, installerOnly bool, skip ...string) (*ArtifactManifest, error) {
+	if installerOnly && naming == nil {
+		return nil, fmt.Errorf("installer-only requires an iss file")
+	}
+	artifacts, err := ScanArtifacts(dir, naming, version, skip...)
+	if err != nil {
+		return nil, err
+	}
+	if installerOnly {
+		var installers []*Artifact
+		for _, a := range artifacts {
+			if a.Installer {
+				installers = append(installers, a)
+			}
+		}
+		artifacts = installers
+	}
+	if len(artifacts) == 0 {
+		return nil, fmt.Errorf("no files found in %s", dir)
+	}
+	return &ArtifactManifest{Version: version, Generated: time.Now(), Files: artifacts}, nil
+}
+
+// HasInstaller 清单中是否包含指定文件名的安装包
+func (m *ArtifactManifest) HasInstaller(name string) bool {
+	for _, a := range m.Files {
+		if filepath.Base(a.File) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteChecksums 写入 sha256sum 格式的校验文件和 json 清单，文件名为空时不写入
+func WriteChecksums(dir string, sumsName string, manifestName string, manifest *ArtifactManifest) error {
+	if sumsName != "" {
+		var b bytes.Buffer
+		for _, a := range manifest.Files {
+			_, _ = fmt.Fprintf(&b, "%s  %s\n", a.SHA256, a.File)
+		}
+		if err := fileutil.WriteFileAtomic(filepath.Join(dir, sumsName), b.Bytes(), 0o644); err != nil {
+			return err
+		}
+	}
+	if manifestName != "" {
+		data, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := fileutil.WriteFileAtomic(filepath.Join(dir, manifestName), append(data, '\n'), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 校验结果
+const (
+	VerifyOK       = "ok"
+	VerifyMismatch = "mismatch"
+	VerifyMissing  = "missing"
+	VerifyUnlisted = "unlisted"
+)
+
+// VerifyResult 单个文件的校验结果
+type VerifyResult struct {
+	File   string `json:"file"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// OK 校验是否通过
+func (r *VerifyResult) OK() bool {
+	return r.Status == VerifyOK
+}
+
+// ParseChecksums 解析 sha256sum 格式，支持二进制模式的 * 前缀
+func ParseChecksums(data []byte) (map[string]string, []string, error) {
+	sums := map[string]string{}
+	var order []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hash, name, ok := strings.Cut(line, " ")
+		if !ok || len(hash) != sha256.Size*2 {
+			return nil, nil, fmt.Errorf("line %d: invalid checksum line", n)
+		}
+		name = strings.TrimPrefix(strings.TrimLeft(name, " "), "*")
+		sums[name] = strings.ToLower(hash)
+		order = append(order, name)
+	}
+	return sums, order, scanner.Err()
+}
+
+// VerifyChecksums 按校验文件检查目录中的文件，清单存在时同时检查大小，未列出的文件标记为 unlisted
+func VerifyChecksums(dir string, sumsName string, manifestName string) ([]*VerifyResult, error) {
+	data, err := os.ReadFile(filepath.Join(dir, sumsName))
+	if err != nil {
+		return nil, fmt.Errorf("读取校验文件失败: %v", err)
+	}
+	sums, order, err := ParseChecksums(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", sumsName, err)
+	}
+	sizes := map[string]int64{}
+	if manifestName != "" && fsutil.FileExist(filepath.Join(dir, manifestName)) {
+		manifest := &ArtifactManifest{}
+		data, err := os.ReadFile(filepath.Join(dir, manifestName))
+		if err != nil {
+			return nil, fmt.Errorf("读取清单失败: %v", err)
+		}
+		if err := json.Unmarshal(data, manifest); err != nil {
+			return nil, fmt.Errorf("解析清单 %s 失败: %v", manifestName, err)
+		}
+		for _, a := range manifest.Files {
+			sizes[a.File] = a.Size
+			if hash, ok := sums[a.File]; !ok || hash != a.SHA256 {
+				return nil, fmt.Errorf("%s and %s disagree on %s", sumsName, manifestName, a.File)
+			}
+		}
+	}
+
+	var results []*VerifyResult
+	for _, name := range order {
+		r := &VerifyResult{File: name, Status: VerifyOK}
+		hash, size, err := fileSHA256(filepath.Join(dir, filepath.FromSlash(name)))
+		switch {
+		case os.IsNotExist(err):
+			r.Status = VerifyMissing
+		case err != nil:
+			r.Status, r.Detail = VerifyMissing, err.Error()
+		case hash != sums[name]:
+			r.Status, r.Detail = VerifyMismatch, "sha256 "+hash
+		default:
+			if expected, ok := sizes[name]; ok && expected != size {
+				r.Status, r.Detail = VerifyMismatch, fmt.Sprintf("size %d, expected %d", size, expected)
+			}
+		}
+		results = append(results, r)
+	}
+
+	artifacts, err := ScanArtifacts(dir, nil, "", sumsName, manifestName)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range artifacts {
+		if _, ok := sums[a.File]; !ok {
+			results = append(results, &VerifyResult{File: a.File, Status: VerifyUnlisted})
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,215 @@
+package obr
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestArtifacts(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "docs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(dir, "demo-1.2.0-setup.exe"), "installer 1.2.0")
+	writeTestFile(t, filepath.Join(dir, "demo-1.1.0-setup.exe"), "installer 1.1.0")
+	writeTestFile(t, filepath.Join(dir, "docs", "README.txt"), "readme")
+	return dir
+}
+
+func verifyStatuses(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	results, err := VerifyChecksums(dir, ChecksumFile, ManifestFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := map[string]string{}
+	for _, r := range results {
+		statuses[r.File] = r.Status
+	}
+	return statuses
+}
+
+func TestISSNaming(t *testing.T) {
+	f := ParseISS([]byte("#define MyAppVersion \"1.2.0\"\r\n[Setup]\r\nOutputBaseFilename=demo-{#MyAppVersion}-setup\r\n"))
+	n := ISSNaming(f, "define:"+ISSVersionDefine)
+	if n.Current != "demo-1.2.0-setup" || n.Version != "1.2.0" || n.InstallerName() != "demo-1.2.0-setup.exe" {
+		t.Fatalf("ISSNaming() = %+v", n)
+	}
+	tests := []struct {
+		name      string
+		installer bool
+		version   string
+	}{
+		{"demo-1.2.0-setup.exe", true, "1.2.0"},
+		{"DEMO-1.1.0-SETUP.EXE", true, "1.1.0"},
+		{"sub/demo-2.0.0-rc.1-setup.exe", true, "2.0.0-rc.1"},
+		{"demo-1.2.0-setup.zip", false, ""},
+		{"other-1.2.0-setup.exe", false, ""},
+	}
+	for _, tt := range tests {
+		if ok, version := n.Match(tt.name); ok != tt.installer || version != tt.version {
+			t.Errorf("Match(%q) = %v, %q, want %v, %q", tt.name, ok, version, tt.installer, tt.version)
+		}
+	}
+
+	// 没有 OutputBaseFilename 时使用 Inno Setup 的默认值，只识别当前版本
+	n = ISSNaming(ParseISS([]byte("[Setup]\r\nAppName=demo\r\n")), "define:"+ISSVersionDefine)
+	if ok, _ := n.Match("mysetup.exe"); !ok || n.InstallerName() != "mysetup.exe" {
+		t.Errorf("default naming = %+v", n)
+	}
+}
+
+func TestScanArtifacts(t *testing.T) {
+	dir := newTestArtifacts(t)
+	writeTestFile(t, filepath.Join(dir, ChecksumFile), "old")
+	f := ParseISS([]byte("#define MyAppVersion \"1.2.0\"\n[Setup]\nOutputBaseFilename=demo-{#MyAppVersion}-setup\n"))
+	artifacts, err := ScanArtifacts(dir, ISSNaming(f, "define:"+ISSVersionDefine), "1.2.0", ChecksumFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, a := range artifacts {
+		got = append(got, fmt.Sprintf("%s:%s:%v:%d", a.File, a.Version, a.Installer, a.Size))
+	}
+	want := "demo-1.1.0-setup.exe:1.1.0:true:15,demo-1.2.0-setup.exe:1.2.0:true:15,docs/README.txt:1.2.0:false:6"
+	if strings.Join(got, ",") != want {
+		t.Errorf("ScanArtifacts() = %s, want %s", strings.Join(got, ","), want)
+	}
+	if artifacts[2].SHA256 != "711a6108ba2ce6ca93dd47d6817f2361db10d8ab6eec89460b2dfc2c325efabe" {
+		t.Errorf("SHA256 = %q", artifacts[2].SHA256)
+	}
+}
+
+func TestChecksumsRoundTrip(t *testing.T) {
+	dir := newTestArtifacts(t)
+	manifest, err := NewArtifactManifest(dir, nil, "1.2.0", false, ChecksumFile, ManifestFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteChecksums(dir, ChecksumFile, ManifestFile, manifest); err != nil {
+		t.Fatal(err)
+	}
+	for file, status := range verifyStatuses(t, dir) {
+		if status != VerifyOK {
+			t.Errorf("%s: %s right after writing", file, status)
+		}
+	}
+
+	// 内容改变、文件删除和新增的文件
+	writeTestFile(t, filepath.Join(dir, "demo-1.2.0-setup.exe"), "tampered 1.2.0")
+	if err := os.Remove(filepath.Join(dir, "demo-1.1.0-setup.exe")); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(dir, "extra.txt"), "extra")
+	want := map[string]string{
+		"demo-1.2.0-setup.exe": VerifyMismatch,
+		"demo-1.1.0-setup.exe": VerifyMissing,
+		"docs/README.txt":      VerifyOK,
+		"extra.txt":            VerifyUnlisted,
+	}
+	got := verifyStatuses(t, dir)
+	if len(got) != len(want) {
+		t.Errorf("VerifyChecksums() = %v, want %v", got, want)
+	}
+	for file, status := range want {
+		if got[file] != status {
+			t.Errorf("%s: %s, want %s", file, got[file], status)
+		}
+	}
+}
+
+func TestChecksumsSizeMismatch(t *testing.T) {
+	dir := newTestArtifacts(t)
+	manifest, err := NewArtifactManifest(dir, nil, "", false, ChecksumFile, ManifestFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 清单记录的大小不同时即使 sha256 相同也不通过
+	manifest.Files[0].Size++
+	if err := WriteChecksums(dir, ChecksumFile, ManifestFile, manifest); err != nil {
+		t.Fatal(err)
+	}
+	if got := verifyStatuses(t, dir)[manifest.Files[0].File]; got != VerifyMismatch {
+		t.Errorf("status = %s, want %s", got, VerifyMismatch)
+	}
+}
+
+func TestParseChecksums(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	data := "# generated\n" + strings.ToUpper(hash) + "  text.txt\n" + hash + " *bin/app.exe\n\n"
+	sums, order, err := ParseChecksums([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(order, ",") != "text.txt,bin/app.exe" || sums["text.txt"] != hash || sums["bin/app.exe"] != hash {
+		t.Errorf("ParseChecksums() = %v, %v", sums, order)
+	}
+	for _, bad := range []string{"abc  short.txt\n", hash + "\n"} {
+		if _, _, err := ParseChecksums([]byte(bad)); err == nil {
+			t.Errorf("ParseChecksums(%q) should fail", bad)
+		}
+	}
+}
+
+func TestVerifyChecksumsBinaryPrefix(t *testing.T) {
+	dir := newTestArtifacts(t)
+	artifacts, err := ScanArtifacts(dir, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	for _, a := range artifacts {
+		fmt.Fprintf(&b, "%s *%s\n", a.SHA256, a.File)
+	}
+	writeTestFile(t, filepath.Join(dir, ChecksumFile), b.String())
+	for file, status := range verifyStatuses(t, dir) {
+		if status != VerifyOK {
+			t.Errorf("%s: %s with * prefix", file, status)
+		}
+	}
+}
+
+func TestVerifyChecksumsManifestDisagrees(t *testing.T) {
+	dir := newTestArtifacts(t)
+	manifest, err := NewArtifactManifest(dir, nil, "", false, ChecksumFile, ManifestFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteChecksums(dir, ChecksumFile, ManifestFile, manifest); err != nil {
+		t.Fatal(err)
+	}
+	// SHA256SUMS 被改写而清单没有更新
+	sums := readTestFile(t, filepath.Join(dir, ChecksumFile))
+	sums = strings.Replace(sums, manifest.Files[0].SHA256, strings.Repeat("0", 64), 1)
+	writeTestFile(t, filepath.Join(dir, ChecksumFile), sums)
+	_, err = VerifyChecksums(dir, ChecksumFile, ManifestFile)
+	if err == nil || !strings.Contains(err.Error(), "disagree on "+manifest.Files[0].File) {
+		t.Errorf("VerifyChecksums() error = %v, want a disagreement", err)
+	}
+
+	// 清单中的文件没有出现在 SHA256SUMS 中
+	lines := strings.SplitN(readTestFile(t, filepath.Join(dir, ChecksumFile)), "\n", 2)
+	writeTestFile(t, filepath.Join(dir, ChecksumFile), lines[1])
+	if _, err := VerifyChecksums(dir, ChecksumFile, ManifestFile); err == nil {
+		t.Error("VerifyChecksums() should fail when a manifest file is not listed")
+	}
+}
+
+func TestNewArtifactManifestInstallerOnly(t *testing.T) {
+	dir := newTestArtifacts(t)
+	if _, err := NewArtifactManifest(dir, nil, "", true); err == nil {
+		t.Error("installer-only without naming should fail")
+	}
+	f := ParseISS([]byte("#define MyAppVersion \"1.2.0\"\n[Setup]\nOutputBaseFilename=demo-{#MyAppVersion}-setup\n"))
+	manifest, err := NewArtifactManifest(dir, ISSNaming(f, "define:"+ISSVersionDefine), "1.2.0", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Files) != 2 || !manifest.HasInstaller("demo-1.2.0-setup.exe") {
+		t.Errorf("installer-only manifest = %+v", manifest.Files)
+	}
+}
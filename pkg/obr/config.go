@@ -0,0 +1,185 @@
+package obr
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFile obr 配置文件的默认名称
+const ConfigFile = ".obr.yaml"
+
+// Config .obr.yaml 配置，命令行参数优先于配置文件。文件路径中的相对路径基于配置文件所在目录 (见 ResolvePaths)。
+// 模板使用 pongo2 语法，可用变量: version, old, new, tag, message。
+type Config struct {
+	// IssPath update-iss 使用的 ISS 文件
+	IssPath string `mapstructure:"iss-path" yaml:"iss-path"`
+	// VersionKey ISS 中记录版本号的键
+	VersionKey string `mapstructure:"version-key" yaml:"version-key"`
+	// VersionFile update-app 使用的版本号文件
+	VersionFile string `mapstructure:"version-file" yaml:"version-file"`
+	// TagTemplate 标签名模板，为空时 update-app 使用 v{{ version }}，其他命令使用 {{ version }}
+	TagTemplate string `mapstructure:"tag-template" yaml:"tag-template"`
+	Remote      string `mapstructure:"remote" yaml:"remote"`
+	// Branches 允许发布的分支
+	Branches []string `mapstructure:"branches" yaml:"branches"`
+	// SignTags 使用 git tag -s 创建签名标签
+	SignTags bool `mapstructure:"sign-tags" yaml:"sign-tags"`
+	// Push 更新版本号后提交、打标签并推送
+	Push          bool   `mapstructure:"push" yaml:"push"`
+	CommitMessage string `mapstructure:"commit-message" yaml:"commit-message"`
+	TagMessage    string `mapstructure:"tag-message" yaml:"tag-message"`
+	ChangelogFile string `mapstructure:"changelog-file" yaml:"changelog-file"`
+	// ReleaseFile 发布记录
+	ReleaseFile string `mapstructure:"release-file" yaml:"release-file"`
+	// Hooks 发布钩子
+	Hooks *Hooks `mapstructure:"hooks" yaml:"hooks,omitempty"`
+	// Pipeline obr release 执行的步骤，为空时使用 DefaultPipeline
+	Pipeline []*PipelineStep `mapstructure:"pipeline" yaml:"pipeline,omitempty"`
+
+	SyncVersion *SyncConfig `mapstructure:"sync-version" yaml:"sync-version,omitempty"`
+}
+
+// DefaultConfig 返回默认配置
+func DefaultConfig() *Config {
+	return &Config{
+		VersionKey:    "define:" + ISSVersionDefine,
+		VersionFile:   "VERSION",
+		Remote:        "origin",
+		Branches:      append([]string{}, DefaultReleaseBranches...),
+		CommitMessage: "update version {{ old }} --> {{ new }}",
+		TagMessage:    "{{ message }}",
+		ChangelogFile: ChangelogFile,
+		ReleaseFile:   ReleaseFile,
+	}
+}
+
+// LoadConfig 读取 obr 配置文件，未设置的值使用默认配置
+func LoadConfig(filename string) (*Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+	c := DefaultConfig()
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("解析配置文件 %s 失败: %v", filename, err)
+	}
+	return c, nil
+}
+
+// 未配置 tag-template 时的标签模板，ISS 版本号的标签不带前缀，VERSION 文件版本号的标签带 v 前缀
+const (
+	ISSTagTemplate = "{{ version }}"
+	AppTagTemplate = "v{{ version }}"
+)
+
+// Tag 按模板生成标签名，TagTemplate 为空时使用 fallback
+func (c *Config) Tag(version string, fallback string) (string, error) {
+	return RenderTemplate(c.tagTemplate(fallback), pongo2.Context{"version": version})
+}
+
+func (c *Config) tagTemplate(fallback string) string {
+	if c.TagTemplate != "" {
+		return c.TagTemplate
+	}
+	return fallback
+}
+
+// paths 返回配置中的文件路径
+func (c *Config) paths() []*string {
+	return []*string{&c.IssPath, &c.VersionFile, &c.ChangelogFile, &c.ReleaseFile}
+}
+
+// ResolvePaths 将配置中的相对路径转换为基于配置文件所在目录 dir 的绝对路径
+func (c *Config) ResolvePaths(dir string) error {
+	for _, p := range c.paths() {
+		if *p == "" {
+			continue
+		}
+		abs, err := filepath.Abs(resolveSyncPath(dir, *p))
+		if err != nil {
+			return err
+		}
+		*p = abs
+	}
+	return nil
+}
+
+// RelativePaths 返回路径相对于 dir 的配置副本，用于输出可以放在 dir 中的配置文件
+func (c *Config) RelativePaths(dir string) *Config {
+	copied := *c
+	for _, p := range copied.paths() {
+		if filepath.IsAbs(*p) {
+			if rel, err := filepath.Rel(dir, *p); err == nil {
+				*p = filepath.ToSlash(rel)
+			}
+		}
+	}
+	return &copied
+}
+
+// ReleasePath 返回发布记录文件的绝对路径，相对路径基于配置文件所在目录 dir
+func (c *Config) ReleasePath(dir string) (string, error) {
+	return filepath.Abs(resolveSyncPath(dir, c.ReleaseFile))
+}
+
+// Messages 按模板生成提交和标签说明
+func (c *Config) Messages(oldVersion, newVersion, tag string) (string, string, error) {
+	ctx := pongo2.Context{"version": newVersion, "old": oldVersion, "new": newVersion, "tag": tag}
+	commitMessage, err := RenderTemplate(c.CommitMessage, ctx)
+	if err != nil {
+		return "", "", err
+	}
+	ctx["message"] = commitMessage
+	tagMessage, err := RenderTemplate(c.TagMessage, ctx)
+	if err != nil {
+		return "", "", err
+	}
+	return commitMessage, tagMessage, nil
+}
+
+// RenderTemplate 渲染配置中的模板，不转义 HTML
+func RenderTemplate(template string, ctx pongo2.Context) (string, error) {
+	tpl, err := pongo2.FromString("{% autoescape off %}" + template + "{% endautoescape %}")
+	if err != nil {
+		return "", fmt.Errorf("invalid template %q: %v", template, err)
+	}
+	return tpl.Execute(ctx)
+}
+
+// TagPatterns 返回匹配标签模板生成的标签的 git 通配符，用于排除 deploy-prod 这样的其他标签。
+// 模板为空时匹配 1.0.0 和 v1.0.0 两种形式
+func TagPatterns(template string) []string {
+	if template == "" {
+		return []string{"[0-9]*", "v[0-9]*", "V[0-9]*"}
+	}
+	rendered, err := RenderTemplate(template, pongo2.Context{"version": "\x00"})
+	if err != nil {
+		return nil
+	}
+	prefix, suffix, ok := strings.Cut(rendered, "\x00")
+	if !ok {
+		return nil
+	}
+	return []string{prefix + "[0-9]*" + suffix}
+}
+
+// VersionFromTag 按标签模板从标签名中取出版本号
+func VersionFromTag(template string, tag string) string {
+	if template == "" {
+		return trimVersionPrefix(tag)
+	}
+	rendered, err := RenderTemplate(template, pongo2.Context{"version": "\x00"})
+	if err != nil {
+		return tag
+	}
+	prefix, suffix, ok := strings.Cut(rendered, "\x00")
+	if !ok {
+		return tag
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(tag, prefix), suffix)
+}
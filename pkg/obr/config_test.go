@@ -0,0 +1,36 @@
+package obr
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigResolvePaths(t *testing.T) {
+	dir := t.TempDir()
+	abs := filepath.Join(t.TempDir(), "setup.iss")
+	c := DefaultConfig()
+	c.IssPath = abs
+	if err := c.ResolvePaths(dir); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"iss-path":       abs,
+		"version-file":   filepath.Join(dir, "VERSION"),
+		"changelog-file": filepath.Join(dir, ChangelogFile),
+		"release-file":   filepath.Join(dir, ReleaseFile),
+	}
+	got := map[string]string{"iss-path": c.IssPath, "version-file": c.VersionFile, "changelog-file": c.ChangelogFile, "release-file": c.ReleaseFile}
+	for key, w := range want {
+		if got[key] != w {
+			t.Errorf("%s = %q, want %q", key, got[key], w)
+		}
+	}
+	if releaseFile, _ := c.ReleasePath(t.TempDir()); releaseFile != want["release-file"] {
+		t.Errorf("ReleasePath() = %q after ResolvePaths", releaseFile)
+	}
+
+	rel := c.RelativePaths(dir)
+	if rel.VersionFile != "VERSION" || rel.ReleaseFile != ReleaseFile || c.VersionFile != want["version-file"] {
+		t.Errorf("RelativePaths() = %q %q, original %q", rel.VersionFile, rel.ReleaseFile, c.VersionFile)
+	}
+}
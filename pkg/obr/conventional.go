@@ -0,0 +1,65 @@
+package obr
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Commit git 提交
+type Commit struct {
+	Hash    string `json:"hash"`
+	Subject string `json:"subject"`
+	Body    string `json:"body,omitempty"`
+}
+
+// ShortHash 返回 7 位提交号
+func (c *Commit) ShortHash() string {
+	if len(c.Hash) > 7 {
+		return c.Hash[:7]
+	}
+	return c.Hash
+}
+
+// ConventionalCommit 按 Conventional Commits 解析后的提交
+//
+//	<type>[(scope)][!]: <description>
+type ConventionalCommit struct {
+	*Commit
+	Type        string `json:"type"`
+	Scope       string `json:"scope,omitempty"`
+	Description string `json:"description"`
+	// Breaking 标题带 ! 或正文包含 BREAKING CHANGE
+	Breaking bool `json:"breaking"`
+	// Conventional 标题是否符合规范，不符合时 Type 为空，Description 为原标题
+	Conventional bool `json:"conventional"`
+}
+
+var (
+	conventionalRe = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?:\s*(.+)$`)
+	breakingRe     = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE:`)
+)
+
+// ParseConventionalCommit 解析提交标题和正文
+func ParseConventionalCommit(c *Commit) *ConventionalCommit {
+	cc := &ConventionalCommit{Commit: c, Description: c.Subject}
+	if m := conventionalRe.FindStringSubmatch(c.Subject); m != nil {
+		cc.Type = strings.ToLower(m[1])
+		cc.Scope = m[2]
+		cc.Breaking = m[3] == "!"
+		cc.Description = m[4]
+		cc.Conventional = true
+	}
+	if breakingRe.MatchString(c.Body) {
+		cc.Breaking = true
+	}
+	return cc
+}
+
+// ParseConventionalCommits 解析多个提交
+func ParseConventionalCommits(commits []*Commit) []*ConventionalCommit {
+	result := make([]*ConventionalCommit, 0, len(commits))
+	for _, c := range commits {
+		result = append(result, ParseConventionalCommit(c))
+	}
+	return result
+}
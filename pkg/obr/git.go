@@ -1,11 +1,284 @@
 package obr
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 )
 
+// Git 发布流程使用的 git 操作，ExecGit 调用 git 命令，FakeGit 在内存中模拟
+type Git interface {
+	// Add 添加文件到暂存区，没有指定文件时添加全部更改
+	Add(files ...string) error
+	Commit(message string) error
+	// Tag 创建带注释的标签
+	Tag(name, message string) error
+	// DeleteTag 删除本地标签
+	DeleteTag(name string) error
+	// PushTags 推送标签到远程仓库
+	PushTags() error
+	// Head 返回当前提交，没有提交时返回空字符串
+	Head() (string, error)
+	// Reset 将当前分支重置到 ref，保留工作区 (git reset --mixed)
+	Reset(ref string) error
+	// Status 返回有改动或未跟踪的文件，为绝对路径
+	Status() ([]string, error)
+	// Branch 返回当前分支名
+	Branch() (string, error)
+	// TagExists 本地是否存在标签
+	TagExists(name string) (bool, error)
+	// RemoteTagExists 远程仓库中是否存在标签
+	RemoteTagExists(name string) (bool, error)
+	// LastTag 返回 ref 之前最近的标签，patterns 不为空时只考虑匹配其中任一通配符的标签，没有标签时返回空字符串
+	LastTag(ref string, patterns ...string) (string, error)
+	// Log 返回 (from, to] 之间的提交，from 为空时返回 to 之前的全部提交
+	Log(from, to string) ([]*Commit, error)
+}
+
+// ExecGit 通过 git 命令实现 Git
+type ExecGit struct {
+	// Dir 执行 git 的目录，为空时为当前目录
+	Dir string
+	// DryRun 只输出会修改仓库的命令，不执行。只读命令 (log, describe) 仍然执行
+	DryRun bool
+	// Remote 推送和检查标签的远程仓库，为空时为 origin
+	Remote string
+	// Sign 使用 git tag -s 创建签名标签
+	Sign   bool
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// NewExecGit 创建在 dir 中执行的 ExecGit，输出到标准输出
+func NewExecGit(dir string, dryRun bool) *ExecGit {
+	return &ExecGit{Dir: dir, DryRun: dryRun, Stdout: os.Stdout, Stderr: os.Stderr}
+}
+
+// run 执行会修改仓库的命令，DryRun 时只输出命令
+func (g *ExecGit) run(args ...string) error {
+	if g.DryRun {
+		_, _ = fmt.Fprintf(g.stdout(), "[dry-run] %s\n", FormatCommand("git", args...))
+		return nil
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.Dir
+	cmd.Stdout = g.stdout()
+	cmd.Stderr = g.Stderr
+	if cmd.Stderr == nil {
+		cmd.Stderr = os.Stderr
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error running git %s: %v", args[0], err)
+	}
+	return nil
+}
+
+// output 执行只读命令并返回标准输出
+func (g *ExecGit) output(args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.Dir
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if stderr.Len() > 0 {
+			return "", fmt.Errorf("error running git %s: %s", args[0], strings.TrimSpace(stderr.String()))
+		}
+		return "", fmt.Errorf("error running git %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+func (g *ExecGit) remote() string {
+	if g.Remote == "" {
+		return "origin"
+	}
+	return g.Remote
+}
+
+func (g *ExecGit) stdout() io.Writer {
+	if g.Stdout == nil {
+		return os.Stdout
+	}
+	return g.Stdout
+}
+
+func (g *ExecGit) Add(files ...string) error {
+	if len(files) == 0 {
+		return g.run("add", ".")
+	}
+	return g.run(append([]string{"add", "--"}, files...)...)
+}
+
+func (g *ExecGit) Commit(message string) error {
+	return g.run("commit", "-m", message)
+}
+
+// Tag 使用 whitespace 清理模式，保留 markdown 标题等以 # 开头的行
+func (g *ExecGit) Tag(name, message string) error {
+	mode := "-a"
+	if g.Sign {
+		mode = "-s"
+	}
+	return g.run("tag", mode, "--cleanup=whitespace", name, "-m", message)
+}
+
+func (g *ExecGit) DeleteTag(name string) error {
+	return g.run("tag", "-d", name)
+}
+
+func (g *ExecGit) Head() (string, error) {
+	out, err := g.output("rev-parse", "--verify", "--quiet", "HEAD")
+	if err != nil {
+		// 没有提交时 rev-parse --quiet 失败且没有输出
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (g *ExecGit) Reset(ref string) error {
+	return g.run("reset", "--quiet", ref)
+}
+
+func (g *ExecGit) Status() ([]string, error) {
+	root, err := g.output("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root = filepath.FromSlash(strings.TrimSpace(root))
+	out, err := g.output("status", "--porcelain", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	records := strings.Split(out, "\x00")
+	for i := 0; i < len(records); i++ {
+		record := records[i]
+		if len(record) < 4 {
+			continue
+		}
+		// 重命名时下一条记录为原路径
+		if record[0] == 'R' || record[0] == 'C' {
+			i++
+		}
+		files = append(files, filepath.Join(root, filepath.FromSlash(record[3:])))
+	}
+	return files, nil
+}
+
+func (g *ExecGit) Branch() (string, error) {
+	out, err := g.output("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (g *ExecGit) TagExists(name string) (bool, error) {
+	out, err := g.output("tag", "--list", name)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(out) != "", nil
+}
+
+func (g *ExecGit) RemoteTagExists(name string) (bool, error) {
+	out, err := g.output("ls-remote", "--tags", g.remote(), "refs/tags/"+name)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(out) != "", nil
+}
+
+func (g *ExecGit) PushTags() error {
+	return g.run("push", g.remote(), "--tags")
+}
+
+func (g *ExecGit) LastTag(ref string, patterns ...string) (string, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	// 没有提交时 tag --merged HEAD 会失败
+	if head, err := g.Head(); err != nil || head == "" {
+		return "", err
+	}
+	// 没有标签时 describe 会失败，先确认是否存在标签
+	tags, err := g.output(append([]string{"tag", "--merged", ref, "--list"}, patterns...)...)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(tags) == "" {
+		return "", nil
+	}
+	args := []string{"describe", "--tags", "--abbrev=0"}
+	for _, pattern := range patterns {
+		args = append(args, "--match", pattern)
+	}
+	out, err := g.output(append(args, ref)...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (g *ExecGit) Log(from, to string) ([]*Commit, error) {
+	if to == "" {
+		to = "HEAD"
+	}
+	revision := to
+	if from != "" {
+		revision = from + ".." + to
+	}
+	// 使用不可见分隔符分隔字段和提交
+	out, err := g.output("log", "--no-merges", "--format=%H%x1f%s%x1f%b%x1e", revision)
+	if err != nil {
+		return nil, err
+	}
+	var commits []*Commit
+	for _, record := range strings.Split(out, "\x1e") {
+		record = strings.TrimLeft(record, "\r\n")
+		if record == "" {
+			continue
+		}
+		fields := strings.SplitN(record, "\x1f", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		commits = append(commits, &Commit{
+			Hash:    fields[0],
+			Subject: fields[1],
+			Body:    strings.TrimSpace(fields[2]),
+		})
+	}
+	return commits, nil
+}
+
+// FormatCommand 将命令格式化为可以复制到终端执行的字符串
+func FormatCommand(name string, args ...string) string {
+	parts := make([]string, 0, len(args)+1)
+	for _, arg := range append([]string{name}, args...) {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'`$\\|&;<>()*?!#") {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
+
+var _ Git = (*ExecGit)(nil)
+
+// defaultGit 包级别函数使用的 Git，在当前目录执行
+var defaultGit Git = NewExecGit("", false)
+
 // GitTagAndPush 封装了 Git 打标签和推送标签的逻辑
 //
 // 参数:
@@ -19,22 +292,38 @@ import (
 //   1. 使用 `git tag -a <tagName> -m <tagMessage>` 创建带注释的 Git 标签。
 //   2. 使用 `git push origin --tags` 将标签推送到远程仓库。
 func GitTagAndPush(tagName, tagMessage string) error {
-	// 执行 `git tag -a <tagName> -m <tagMessage>`
-	cmdTag := exec.Command("git", "tag", "-a", tagName, "-m", tagMessage)
-	cmdTag.Stdout = os.Stdout
-	cmdTag.Stderr = os.Stderr
-	if err := cmdTag.Run(); err != nil {
-		return fmt.Errorf("error running git tag: %v", err)
-	}
+	return TagAndPush(defaultGit, tagName, tagMessage)
+}
 
-	// 执行 `git push origin --tags`
-	cmdPush := exec.Command("git", "push", "origin", "--tags")
-	cmdPush.Stdout = os.Stdout
-	cmdPush.Stderr = os.Stderr
-	if err := cmdPush.Run(); err != nil {
-		return fmt.Errorf("error running git push: %v", err)
+// CommitChanged 提交 files 中有改动的文件，没有改动时不提交并返回 false
+func CommitChanged(git Git, message string, files ...string) (bool, error) {
+	status, err := git.Status()
+	if err != nil {
+		return false, err
+	}
+	var changed []string
+	for _, f := range files {
+		if containsPath(status, f) {
+			changed = append(changed, f)
+		}
+	}
+	if len(changed) == 0 {
+		return false, nil
+	}
+	if err := git.Add(changed...); err != nil {
+		return false, err
 	}
+	return true, git.Commit(message)
+}
 
+// TagAndPush 使用指定的 Git 打标签并推送
+func TagAndPush(git Git, tagName, tagMessage string) error {
+	if err := git.Tag(tagName, tagMessage); err != nil {
+		return err
+	}
+	if err := git.PushTags(); err != nil {
+		return err
+	}
 	fmt.Println("Git tag and push successful.")
 	return nil
 }
@@ -52,35 +341,32 @@ func GitTagAndPush(tagName, tagMessage string) error {
 //   1. 使用 `git add` 添加指定文件到暂存区。如果未提供文件，则添加全部更改。
 //   2. 使用 `git commit -m <commitMessage>` 提交更改到 Git 仓库。
 func GitCommitChange(commitMessage string, commitFiles ...string) error {
-	// 判断是否有需要提交的指定文件
-	if len(commitFiles) > 0 {
-		// 逐个添加指定文件
-		for _, commitFile := range commitFiles {
-			cmdAdd := exec.Command("git", "add", commitFile)
-			cmdAdd.Stdout = os.Stdout
-			cmdAdd.Stderr = os.Stderr
-			if err := cmdAdd.Run(); err != nil {
-				return fmt.Errorf("error running git add: %v", err)
-			}
-		}
-	} else {
-		// 没有指定文件，默认添加全部文件
-		cmdAdd := exec.Command("git", "add", ".")
-		cmdAdd.Stdout = os.Stdout
-		cmdAdd.Stderr = os.Stderr
-		if err := cmdAdd.Run(); err != nil {
-			return fmt.Errorf("error running git add: %v", err)
-		}
-	}
+	return CommitChange(defaultGit, commitMessage, commitFiles...)
+}
 
-	// 提交更改
-	cmdCommit := exec.Command("git", "commit", "-m", commitMessage)
-	cmdCommit.Stdout = os.Stdout
-	cmdCommit.Stderr = os.Stderr
-	if err := cmdCommit.Run(); err != nil {
-		return fmt.Errorf("error running git commit: %v", err)
+// CommitChange 使用指定的 Git 添加文件并提交
+func CommitChange(git Git, commitMessage string, commitFiles ...string) error {
+	if err := git.Add(commitFiles...); err != nil {
+		return err
+	}
+	if err := git.Commit(commitMessage); err != nil {
+		return err
 	}
-
 	fmt.Println("Git commit successful.")
 	return nil
 }
+
+// GitTag 使用 `git tag -a <tagName> -m <tagMessage>` 创建带注释的标签
+func GitTag(tagName, tagMessage string) error {
+	return defaultGit.Tag(tagName, tagMessage)
+}
+
+// GitLastTag 返回 ref 之前最近的标签，patterns 不为空时只考虑匹配其中任一通配符的标签，没有标签时返回空字符串
+func GitLastTag(ref string, patterns ...string) (string, error) {
+	return defaultGit.LastTag(ref, patterns...)
+}
+
+// GitLog 返回 (from, to] 之间的提交，from 为空时返回 to 之前的全部提交
+func GitLog(from, to string) ([]*Commit, error) {
+	return defaultGit.Log(from, to)
+}
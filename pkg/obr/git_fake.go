@@ -0,0 +1,219 @@
+package obr
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// FakeTag FakeGit 中的标签
+type FakeTag struct {
+	Name    string
+	Message string
+	// Commit 标签指向的提交在 Commits 中的位置
+	Commit int
+	Pushed bool
+}
+
+// FakeGit 在内存中模拟 Git，用于测试和演练发布流程。
+// 所有调用按顺序记录在 Calls 中，Errors 按命令名 (add, commit, tag, push, reset) 注入错误。
+type FakeGit struct {
+	// BranchName 当前分支，为空时为 main
+	BranchName string
+	// Changed 工作区中有改动的文件
+	Changed []string
+	Staged  []string
+	Commits []*Commit
+	Tags    []*FakeTag
+	Calls   []string
+	Errors  map[string]error
+}
+
+var _ Git = (*FakeGit)(nil)
+
+// NewFakeGit 创建空仓库
+func NewFakeGit() *FakeGit {
+	return &FakeGit{Errors: map[string]error{}}
+}
+
+func (g *FakeGit) call(name string, args ...string) error {
+	g.Calls = append(g.Calls, FormatCommand("git", append([]string{name}, args...)...))
+	if err := g.Errors[name]; err != nil {
+		return fmt.Errorf("error running git %s: %v", name, err)
+	}
+	return nil
+}
+
+func (g *FakeGit) Add(files ...string) error {
+	if err := g.call("add", files...); err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		files = g.Changed
+	}
+	g.Staged = append(g.Staged, files...)
+	return nil
+}
+
+func (g *FakeGit) Commit(message string) error {
+	if err := g.call("commit", "-m", message); err != nil {
+		return err
+	}
+	if len(g.Staged) == 0 {
+		return fmt.Errorf("error running git commit: nothing to commit")
+	}
+	g.AddCommit(message)
+	changed := g.Changed[:0]
+	for _, f := range g.Changed {
+		if !containsPath(g.Staged, f) {
+			changed = append(changed, f)
+		}
+	}
+	g.Changed = changed
+	g.Staged = nil
+	return nil
+}
+
+// AddCommit 直接添加提交，用于准备测试数据。message 第一行为标题，其余为正文
+func (g *FakeGit) AddCommit(message string) *Commit {
+	subject, body, _ := strings.Cut(message, "\n")
+	c := &Commit{Hash: fmt.Sprintf("%040x", len(g.Commits)+1), Subject: subject, Body: strings.TrimSpace(body)}
+	g.Commits = append(g.Commits, c)
+	return c
+}
+
+func (g *FakeGit) findTag(name string) *FakeTag {
+	for _, t := range g.Tags {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+func (g *FakeGit) Tag(name, message string) error {
+	if err := g.call("tag", "-a", name, "-m", message); err != nil {
+		return err
+	}
+	if g.findTag(name) != nil {
+		return fmt.Errorf("error running git tag: tag '%s' already exists", name)
+	}
+	if len(g.Commits) == 0 {
+		return fmt.Errorf("error running git tag: no commits")
+	}
+	g.Tags = append(g.Tags, &FakeTag{Name: name, Message: message, Commit: len(g.Commits) - 1})
+	return nil
+}
+
+func (g *FakeGit) DeleteTag(name string) error {
+	if err := g.call("tag", "-d", name); err != nil {
+		return err
+	}
+	for i, t := range g.Tags {
+		if t.Name == name {
+			g.Tags = append(g.Tags[:i], g.Tags[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("error running git tag: tag '%s' not found", name)
+}
+
+func (g *FakeGit) Head() (string, error) {
+	if len(g.Commits) == 0 {
+		return "", nil
+	}
+	return g.Commits[len(g.Commits)-1].Hash, nil
+}
+
+// Reset 只支持 Commits 中的提交号
+func (g *FakeGit) Reset(ref string) error {
+	if err := g.call("reset", "--quiet", ref); err != nil {
+		return err
+	}
+	for i, c := range g.Commits {
+		if c.Hash == ref {
+			g.Commits = g.Commits[:i+1]
+			g.Staged = nil
+			return nil
+		}
+	}
+	return fmt.Errorf("error running git reset: unknown revision %s", ref)
+}
+
+func (g *FakeGit) PushTags() error {
+	if err := g.call("push", "origin", "--tags"); err != nil {
+		return err
+	}
+	for _, t := range g.Tags {
+		t.Pushed = true
+	}
+	return nil
+}
+
+// LastTag 只支持 HEAD
+func (g *FakeGit) LastTag(ref string, patterns ...string) (string, error) {
+	var last *FakeTag
+	for _, t := range g.Tags {
+		if !matchTag(t.Name, patterns) {
+			continue
+		}
+		if last == nil || t.Commit >= last.Commit {
+			last = t
+		}
+	}
+	if last == nil {
+		return "", nil
+	}
+	return last.Name, nil
+}
+
+// Log 只支持以标签为起点、HEAD 为终点，按时间倒序返回
+func (g *FakeGit) Log(from, to string) ([]*Commit, error) {
+	start := 0
+	if from != "" {
+		t := g.findTag(from)
+		if t == nil {
+			return nil, fmt.Errorf("error running git log: unknown revision %s", from)
+		}
+		start = t.Commit + 1
+	}
+	var commits []*Commit
+	for i := len(g.Commits) - 1; i >= start; i-- {
+		commits = append(commits, g.Commits[i])
+	}
+	return commits, nil
+}
+
+func (g *FakeGit) Status() ([]string, error) {
+	return append([]string{}, g.Changed...), nil
+}
+
+func (g *FakeGit) Branch() (string, error) {
+	if g.BranchName == "" {
+		return "main", nil
+	}
+	return g.BranchName, nil
+}
+
+func (g *FakeGit) TagExists(name string) (bool, error) {
+	return g.findTag(name) != nil, nil
+}
+
+// RemoteTagExists 已推送的标签视为远程存在
+func (g *FakeGit) RemoteTagExists(name string) (bool, error) {
+	t := g.findTag(name)
+	return t != nil && t.Pushed, nil
+}
+
+// matchTag 标签名匹配任一通配符，patterns 为空时全部匹配
+func matchTag(name string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,178 @@
+package obr
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runGit 在 dir 中执行 git，失败时结束测试
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// newTestRepo 在临时目录中创建 bare 远程仓库并克隆，返回克隆目录和远程目录
+func newTestRepo(t *testing.T) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	root := t.TempDir()
+	remote := filepath.Join(root, "remote.git")
+	repo := filepath.Join(root, "repo")
+	runGit(t, root, "init", "--quiet", "--bare", remote)
+	runGit(t, root, "clone", "--quiet", remote, repo)
+	runGit(t, repo, "config", "user.name", "test")
+	runGit(t, repo, "config", "user.email", "test@example.com")
+	runGit(t, repo, "config", "commit.gpgsign", "false")
+	runGit(t, repo, "config", "tag.gpgsign", "false")
+	return repo, remote
+}
+
+func writeTestFile(t *testing.T, filename string, content string) {
+	t.Helper()
+	if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestExecGit(dir string) (*ExecGit, *bytes.Buffer) {
+	var out bytes.Buffer
+	return &ExecGit{Dir: dir, Stdout: &out, Stderr: &out}, &out
+}
+
+func TestExecGit(t *testing.T) {
+	repo, remote := newTestRepo(t)
+	git, _ := newTestExecGit(repo)
+
+	head, err := git.Head()
+	if err != nil || head != "" {
+		t.Fatalf("Head() on empty repo = %q, %v", head, err)
+	}
+	tag, err := git.LastTag("")
+	if err != nil || tag != "" {
+		t.Fatalf("LastTag() without tags = %q, %v", tag, err)
+	}
+
+	writeTestFile(t, filepath.Join(repo, "VERSION"), "1.0.0\n")
+	writeTestFile(t, filepath.Join(repo, "other.txt"), "x\n")
+	status, err := git.Status()
+	if err != nil || len(status) != 2 {
+		t.Fatalf("Status() = %v, %v", status, err)
+	}
+	if err := CommitChange(git, "feat: first", filepath.Join(repo, "VERSION")); err != nil {
+		t.Fatal(err)
+	}
+	// 只提交指定的文件
+	status, _ = git.Status()
+	if len(status) != 1 || filepath.Base(status[0]) != "other.txt" {
+		t.Fatalf("Status() after commit = %v", status)
+	}
+	if err := git.Tag("v1.0.0", "## 1.0.0\n\n- first"); err != nil {
+		t.Fatal(err)
+	}
+	if err := git.PushTags(); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := git.RemoteTagExists("v1.0.0"); err != nil || !ok {
+		t.Fatalf("RemoteTagExists(v1.0.0) = %v, %v", ok, err)
+	}
+	if got := runGit(t, remote, "tag", "--list"); got != "v1.0.0" {
+		t.Fatalf("remote tags = %q", got)
+	}
+	// whitespace 清理模式保留 markdown 标题
+	if got := runGit(t, repo, "tag", "--list", "--format=%(contents:subject)", "v1.0.0"); got != "## 1.0.0" {
+		t.Fatalf("tag message subject = %q", got)
+	}
+
+	writeTestFile(t, filepath.Join(repo, "VERSION"), "1.1.0\n")
+	if err := CommitChange(git, "fix: second\n\nbody", filepath.Join(repo, "VERSION")); err != nil {
+		t.Fatal(err)
+	}
+	tag, err = git.LastTag("HEAD")
+	if err != nil || tag != "v1.0.0" {
+		t.Fatalf("LastTag(HEAD) = %q, %v", tag, err)
+	}
+	commits, err := git.Log(tag, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 1 || commits[0].Subject != "fix: second" || commits[0].Body != "body" {
+		t.Fatalf("Log(v1.0.0, HEAD) = %+v", commits)
+	}
+	if commits, _ = git.Log("", ""); len(commits) != 2 {
+		t.Fatalf("Log() = %d commits, want 2", len(commits))
+	}
+
+	branch, err := git.Branch()
+	if err != nil || branch == "" {
+		t.Fatalf("Branch() = %q, %v", branch, err)
+	}
+	if err := git.DeleteTag("v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := git.TagExists("v1.0.0"); ok {
+		t.Fatal("tag v1.0.0 still exists after DeleteTag")
+	}
+}
+
+func TestExecGitDryRun(t *testing.T) {
+	repo, remote := newTestRepo(t)
+	git, out := newTestExecGit(repo)
+	writeTestFile(t, filepath.Join(repo, "VERSION"), "1.0.0\n")
+	if err := CommitChange(git, "init", "VERSION"); err != nil {
+		t.Fatal(err)
+	}
+	head, _ := git.Head()
+
+	git.DryRun = true
+	out.Reset()
+	writeTestFile(t, filepath.Join(repo, "VERSION"), "1.0.1\n")
+	if err := git.Add("VERSION"); err != nil {
+		t.Fatal(err)
+	}
+	if err := git.Commit("update version 1.0.0 --> 1.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := TagAndPush(git, "v1.0.1", "release 1.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"[dry-run] git add -- VERSION",
+		"[dry-run] git commit -m 'update version 1.0.0 --> 1.0.1'",
+		"[dry-run] git tag -a --cleanup=whitespace v1.0.1 -m 'release 1.0.1'",
+		"[dry-run] git push origin --tags",
+	}
+	if got := strings.TrimSpace(out.String()); got != strings.Join(want, "\n") {
+		t.Fatalf("dry-run output:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+
+	// 只输出命令，仓库不变
+	if got, _ := git.Head(); got != head {
+		t.Fatalf("HEAD changed in dry-run: %s -> %s", head, got)
+	}
+	if ok, _ := git.TagExists("v1.0.1"); ok {
+		t.Fatal("tag created in dry-run")
+	}
+	if got := runGit(t, remote, "tag", "--list"); got != "" {
+		t.Fatalf("remote tags after dry-run = %q", got)
+	}
+}
+
+func TestFormatCommand(t *testing.T) {
+	got := FormatCommand("git", "commit", "-m", "it's done", "")
+	want := `git commit -m 'it'\''s done' ''`
+	if got != want {
+		t.Fatalf("FormatCommand() = %s, want %s", got, want)
+	}
+}
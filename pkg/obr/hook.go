@@ -0,0 +1,127 @@
+package obr
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"win_helper/pkg/util/command"
+)
+
+// 发布钩子
+const (
+	HookPreBump  = "pre-bump"
+	HookPostBump = "post-bump"
+	HookPreTag   = "pre-tag"
+	HookPostPush = "post-push"
+)
+
+// Hooks 发布各阶段执行的命令，通过系统 shell 执行，
+// 环境变量 OLD_VERSION、NEW_VERSION、TAG 为本次发布的版本号和标签
+type Hooks struct {
+	PreBump  []string `mapstructure:"pre-bump" yaml:"pre-bump,omitempty" json:"pre-bump,omitempty"`
+	PostBump []string `mapstructure:"post-bump" yaml:"post-bump,omitempty" json:"post-bump,omitempty"`
+	PreTag   []string `mapstructure:"pre-tag" yaml:"pre-tag,omitempty" json:"pre-tag,omitempty"`
+	PostPush []string `mapstructure:"post-push" yaml:"post-push,omitempty" json:"post-push,omitempty"`
+}
+
+// Commands 返回钩子的命令
+func (h *Hooks) Commands(hook string) []string {
+	if h == nil {
+		return nil
+	}
+	switch hook {
+	case HookPreBump:
+		return h.PreBump
+	case HookPostBump:
+		return h.PostBump
+	case HookPreTag:
+		return h.PreTag
+	case HookPostPush:
+		return h.PostPush
+	}
+	return nil
+}
+
+// HookResult 钩子命令的执行结果
+type HookResult struct {
+	Hook     string        `json:"hook"`
+	Command  string        `json:"command"`
+	Output   string        `json:"output,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+}
+
+// HookEnv 返回钩子的环境变量
+func HookEnv(oldVersion, newVersion, tag string) []string {
+	return []string{"OLD_VERSION=" + oldVersion, "NEW_VERSION=" + newVersion, "TAG=" + tag}
+}
+
+// RunHook 依次执行钩子的命令，输出写入发布日志，任一命令失败时返回错误。
+// post-push 之前的钩子改动或新增的文件 (如重新生成的资源) 随发布一起提交，
+// 已经提交之后 (pre-tag) 的改动追加一次提交，保证标签指向包含这些文件的提交。
+func (r *Release) RunHook(hook string) error {
+	commands := r.Hooks.Commands(hook)
+	track := len(commands) > 0 && !r.DryRun && hook != HookPostPush
+	var before []string
+	if track {
+		var err error
+		if before, err = r.Git.Status(); err != nil {
+			return err
+		}
+	}
+	for _, line := range commands {
+		if r.DryRun {
+			r.printf("[dry-run] hook %s: %s\n", hook, line)
+			continue
+		}
+		r.printf("hook %s: %s\n", hook, line)
+		result := &HookResult{Hook: hook, Command: line, Start: time.Now()}
+		out, err := command.RunCommandEnv(r.Dir, r.Env, command.ShellArgs(line)...)
+		result.Duration = time.Since(result.Start)
+		result.Output = out
+		if out != "" {
+			r.printf("%s", out)
+			if !strings.HasSuffix(out, "\n") {
+				r.printf("\n")
+			}
+		}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		r.hooks = append(r.hooks, result)
+		if err != nil {
+			return fmt.Errorf("hook %s failed: %s: %v", hook, line, err)
+		}
+	}
+	if !track {
+		return nil
+	}
+	return r.addHookFiles(hook, before)
+}
+
+// addHookFiles 将钩子执行后新出现在 git status 中的文件加入发布
+func (r *Release) addHookFiles(hook string, before []string) error {
+	after, err := r.Git.Status()
+	if err != nil {
+		return err
+	}
+	var changed []string
+	for _, f := range after {
+		if !containsPath(before, f) && !containsPath(r.files, f) {
+			changed = append(changed, f)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	for _, f := range changed {
+		r.printf("hook %s changed %s\n", hook, f)
+	}
+	r.files = append(r.files, changed...)
+	if r.commit == "" {
+		return nil
+	}
+	return r.Commit(fmt.Sprintf("%s (%s hook)", r.message, hook))
+}
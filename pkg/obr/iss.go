@@ -1,97 +1,163 @@
 package obr
 
 import (
-	"bufio"
 	"fmt"
-	"os"
-	"regexp"
+	"path/filepath"
 	"strings"
+
+	"win_helper/pkg/util/fileutil"
 )
 
-func GetCurrentISSVersion(filePath string) (string, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
+// ISSVersionDefine 记录版本号的 #define 名称
+const ISSVersionDefine = "MyAppVersion"
 
-	// 使用正则表达式匹配版本号
-	versionPattern := `#define\s+MyAppVersion\s+"([\d\.]+)"`
+// ISS 键的类型
+const (
+	ISSKeyDefine = "define"
+	ISSKeySetup  = "setup"
+)
 
-	// 编译正则表达式
-	re := regexp.MustCompile(versionPattern)
+// ISSValue ISS 脚本中 #define 或 [Setup] 键的值
+type ISSValue struct {
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	// Expanded 为替换 {#Name} 之后的值
+	Expanded string `json:"expanded"`
+}
 
-	// 创建一个 bufio Scanner 来逐行读取文件
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
+// parseISSKey 解析 "define:Name"、"setup:Key" 或不带前缀的键，不带前缀时返回空类型
+func parseISSKey(key string) (string, string) {
+	if kind, name, ok := strings.Cut(key, ":"); ok {
+		switch strings.ToLower(kind) {
+		case ISSKeyDefine, ISSKeySetup:
+			return strings.ToLower(kind), name
+		}
+	}
+	return "", key
+}
 
-		// 匹配正则表达式
-		matches := re.FindStringSubmatch(line)
-		if len(matches) > 1 {
-			version := matches[1]
-			fmt.Println("Found version:", version)
-			return version, nil
+// Get 读取 #define 或 [Setup] 键的值，不带前缀的键先查找 #define 再查找 [Setup]
+func (f *ISSFile) Get(key string) (*ISSValue, error) {
+	kind, name := parseISSKey(key)
+	if kind == "" || kind == ISSKeyDefine {
+		if line := f.Define(name); line != nil {
+			return &ISSValue{Kind: ISSKeyDefine, Name: line.Name, Value: line.Value, Expanded: f.Expand(line.Value)}, nil
+		}
+	}
+	if kind == "" || kind == ISSKeySetup {
+		if line := f.Setup(name); line != nil {
+			return &ISSValue{Kind: ISSKeySetup, Name: line.Name, Value: line.Value, Expanded: f.Expand(line.Value)}, nil
 		}
 	}
+	return nil, fmt.Errorf("%s not found", key)
+}
 
-	if err := scanner.Err(); err != nil {
-		fmt.Println("Error reading file:", err)
-		return "", fmt.Errorf("Error reading file:", err)
-	} else {
-		return "", fmt.Errorf("Version not found.")
+// Set 修改 #define 或 [Setup] 键的值并返回修改前的值。
+// 键不存在时，create 为 true 且指定了类型前缀则新增，否则返回错误。
+func (f *ISSFile) Set(key string, value string, create bool) (*ISSValue, error) {
+	old, err := f.Get(key)
+	if err != nil {
+		kind, name := parseISSKey(key)
+		if !create || kind == "" {
+			return nil, err
+		}
+		old = &ISSValue{Kind: kind, Name: name}
+	}
+	switch old.Kind {
+	case ISSKeyDefine:
+		f.SetDefine(old.Name, value)
+	case ISSKeySetup:
+		f.SetSetup(old.Name, value)
 	}
+	return old, nil
 }
 
-func SaveISSVersion(version string, filePath string) error {
-	// 打开原始文件进行读取
-	inputFile, err := os.Open(filePath)
+// GetISSValue 读取 ISS 文件中 #define 或 [Setup] 键的值
+func GetISSValue(filePath string, key string) (*ISSValue, error) {
+	f, err := LoadISS(filePath)
 	if err != nil {
-		return fmt.Errorf("error opening file: %v", err)
+		return nil, err
 	}
-	defer inputFile.Close()
+	return f.Get(key)
+}
 
-	// 创建一个临时文件，用于保存修改后的内容
-	tempFile, err := os.CreateTemp("", "temp_*.iss")
+// SaveISSValue 修改 ISS 文件中 #define 或 [Setup] 键的值
+func SaveISSValue(filePath string, key string, value string, create bool) (*ISSValue, error) {
+	f, err := LoadISS(filePath)
 	if err != nil {
-		return fmt.Errorf("error creating temporary file: %v", err)
+		return nil, err
 	}
-	defer tempFile.Close()
+	old, err := f.Set(key, value, create)
+	if err != nil {
+		return nil, err
+	}
+	return old, f.Save(filePath)
+}
 
-	// 使用 bufio.Scanner 逐行读取并处理文件
-	scanner := bufio.NewScanner(inputFile)
-	writer := bufio.NewWriter(tempFile)
-	defer writer.Flush()
+func GetCurrentISSVersion(filePath string) (string, error) {
+	return GetISSVersion(filePath, ISSVersionDefine)
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()
+// GetISSVersion 读取指定键记录的版本号
+func GetISSVersion(filePath string, key string) (string, error) {
+	v, err := GetISSValue(filePath, key)
+	if err != nil {
+		return "", fmt.Errorf("version not found: %v", err)
+	}
+	fmt.Println("Found version:", v.Value)
+	return v.Value, nil
+}
 
-		// 查找并替换版本号
-		if strings.Contains(line, "#define MyAppVersion") {
-			line = fmt.Sprintf("#define MyAppVersion \"%s\"", version)
-		}
+func SaveISSVersion(version string, filePath string) error {
+	return SaveISSVersionKey(version, filePath, ISSVersionDefine)
+}
 
-		// 将处理后的行写入临时文件
-		_, err := writer.WriteString(line + "\n")
-		if err != nil {
-			return fmt.Errorf("error writing to temporary file: %v", err)
-		}
-	}
+// SaveISSVersionKey 将版本号写入指定键
+func SaveISSVersionKey(version string, filePath string, key string) error {
+	_, err := SaveISSValue(filePath, key, version, false)
+	return err
+}
 
-	// 检查读取文件时的错误
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading file: %v", err)
+// Save 按原编码将脚本写回文件，在同一目录中写入临时文件后替换，保留原文件权限
+func (f *ISSFile) Save(filePath string) error {
+	data, err := fileutil.EncodeText(f.Bytes(), f.Encoding, f.hasBOM)
+	if err != nil {
+		return err
+	}
+	if err := fileutil.WriteFileAtomic(filePath, data, 0o644); err != nil {
+		return fmt.Errorf("error writing file: %v", err)
 	}
+	return nil
+}
 
-	// 删除原始文件
-	if err := os.Remove(filePath); err != nil {
-		return fmt.Errorf("error removing original file: %v", err)
+// SetupValue 返回 [Setup] 中替换 {#Name} 之后的值，不存在时返回 def
+func (f *ISSFile) SetupValue(key string, def string) string {
+	if line := f.Setup(key); line != nil {
+		return f.Expand(line.Value)
 	}
+	return def
+}
 
-	// 重命名临时文件为原始文件名
-	if err := os.Rename(tempFile.Name(), filePath); err != nil {
-		return fmt.Errorf("error renaming temporary file: %v", err)
+// SourceDir 返回 [Files] 等相对路径的基准目录，未设置 SourceDir 时为脚本所在目录
+func (f *ISSFile) SourceDir(issPath string) string {
+	dir := filepath.Dir(issPath)
+	source := f.SetupValue("SourceDir", "")
+	if source == "" {
+		return dir
+	}
+	source = filepath.FromSlash(strings.ReplaceAll(source, `\`, "/"))
+	if filepath.IsAbs(source) || filepath.VolumeName(source) != "" {
+		return source
 	}
+	return filepath.Join(dir, source)
+}
 
-	return nil
+// OutputDir 返回安装包输出目录，相对路径基于 SourceDir，默认为 Output
+func (f *ISSFile) OutputDir(issPath string) string {
+	output := filepath.FromSlash(strings.ReplaceAll(f.SetupValue("OutputDir", "Output"), `\`, "/"))
+	if filepath.IsAbs(output) || filepath.VolumeName(output) != "" {
+		return output
+	}
+	return filepath.Join(f.SourceDir(issPath), output)
 }
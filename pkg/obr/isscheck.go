@@ -0,0 +1,305 @@
+package obr
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// [Files] 检查发现的问题
+const (
+	ISSMissingFile   = "missing"
+	ISSEmptyGlob     = "empty-glob"
+	ISSDuplicateDest = "duplicate-dest"
+	ISSUnresolved    = "unresolved"
+)
+
+// ISSProblem [Files] 条目的问题
+type ISSProblem struct {
+	// Line 条目所在行，从 1 开始
+	Line   int    `json:"line"`
+	Kind   string `json:"kind"`
+	Source string `json:"source"`
+	Dest   string `json:"dest,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func (p *ISSProblem) String() string {
+	s := p.Kind + " " + p.Source
+	if p.Dest != "" {
+		s += " -> " + p.Dest
+	}
+	if p.Detail != "" {
+		s += ": " + p.Detail
+	}
+	return s
+}
+
+// ISSFileEntry 解析后的 [Files] 条目
+type ISSFileEntry struct {
+	Line int `json:"line"`
+	// Source 替换 {#Name} 之后的源路径
+	Source string `json:"source"`
+	// Files 匹配到的文件，为相对 SourceDir 的路径
+	Files []string `json:"files"`
+	// Dests 安装后的目标路径，与 Files 一一对应
+	Dests []string `json:"dests"`
+	// Conditional 条目带有 Components、Tasks、Languages 或 Check，可能不会安装
+	Conditional bool   `json:"conditional,omitempty"`
+	Skipped     string `json:"skipped,omitempty"`
+}
+
+// ISSCheckResult [Files] 检查结果
+type ISSCheckResult struct {
+	SourceDir string          `json:"sourceDir"`
+	Entries   []*ISSFileEntry `json:"entries"`
+	Problems  []*ISSProblem   `json:"problems"`
+}
+
+// OK 没有发现问题
+func (r *ISSCheckResult) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// CheckISSFiles 按 SourceDir 和 #define 解析 [Files] 中的每个 Source，
+// 检查文件是否存在、通配符是否匹配到文件以及多个条目是否安装到同一个目标。
+// 只处理 #define 和 SourcePath 等 ISPP 内置变量，不处理 #include 和 #if 等其他预处理指令，
+// 引用 CompilerPath 等编译时才能确定的内置变量的条目会跳过。
+func CheckISSFiles(f *ISSFile, issPath string) *ISSCheckResult {
+	result := &ISSCheckResult{SourceDir: f.SourceDir(issPath), Problems: []*ISSProblem{}}
+	lineNumbers := map[*ISSLine]int{}
+	for i, line := range f.Lines {
+		lineNumbers[line] = i + 1
+	}
+
+	for _, line := range f.Entries("files") {
+		source, _ := line.Param("Source")
+		entry := &ISSFileEntry{Line: lineNumbers[line], Source: f.Expand(source)}
+		result.Entries = append(result.Entries, entry)
+		problem := func(kind string, detail string) {
+			result.Problems = append(result.Problems, &ISSProblem{Line: entry.Line, Kind: kind, Source: entry.Source, Detail: detail})
+		}
+
+		flags := issFlags(line)
+		switch {
+		case source == "":
+			problem(ISSMissingFile, "no Source parameter")
+			continue
+		case flags["external"]:
+			// 安装时才读取的文件无法在编译前检查
+			entry.Skipped = "external"
+			continue
+		case issCompilerBuiltin(entry.Source) != "":
+			// 只有 ISCC 编译时才知道的值，无法检查，不作为错误
+			entry.Skipped = "{#" + issCompilerBuiltin(entry.Source) + "}"
+			continue
+		case issExpandPattern.MatchString(entry.Source):
+			problem(ISSUnresolved, "undefined "+issExpandPattern.FindString(entry.Source))
+			continue
+		}
+		for _, name := range []string{"Components", "Tasks", "Languages", "Check"} {
+			if _, ok := line.Param(name); ok {
+				entry.Conditional = true
+			}
+		}
+
+		files, err := resolveISSSource(result.SourceDir, entry.Source, flags["recursesubdirs"], issExcludes(f, line))
+		isGlob := strings.ContainsAny(entry.Source, "*?")
+		switch {
+		case err != nil:
+			problem(ISSMissingFile, err.Error())
+			continue
+		case len(files) == 0 && flags["skipifsourcedoesntexist"]:
+			entry.Skipped = "skipifsourcedoesntexist"
+			continue
+		case len(files) == 0 && isGlob:
+			problem(ISSEmptyGlob, "no files match")
+			continue
+		case len(files) == 0:
+			problem(ISSMissingFile, "file not found")
+			continue
+		}
+
+		destDir, _ := line.Param("DestDir")
+		destDir = issSlash(f.Expand(destDir))
+		destName, hasDestName := line.Param("DestName")
+		for _, file := range files {
+			dest := path.Join(destDir, path.Base(file.rel))
+			if isGlob && flags["recursesubdirs"] {
+				dest = path.Join(destDir, file.sub)
+			}
+			if hasDestName {
+				dest = path.Join(destDir, issSlash(f.Expand(destName)))
+			}
+			entry.Files = append(entry.Files, file.rel)
+			entry.Dests = append(entry.Dests, dest)
+		}
+	}
+
+	result.Problems = append(result.Problems, duplicateISSDests(result.Entries)...)
+	sort.SliceStable(result.Problems, func(i, j int) bool { return result.Problems[i].Line < result.Problems[j].Line })
+	return result
+}
+
+// duplicateISSDests 查找安装到同一目标的文件，目标不区分大小写。带条件的条目可能互斥，不参与检查。
+func duplicateISSDests(entries []*ISSFileEntry) []*ISSProblem {
+	type owner struct {
+		entry *ISSFileEntry
+		file  string
+	}
+	var problems []*ISSProblem
+	seen := map[string]owner{}
+	for _, entry := range entries {
+		if entry.Conditional {
+			continue
+		}
+		for i, dest := range entry.Dests {
+			key := strings.ToLower(dest)
+			first, ok := seen[key]
+			if !ok {
+				seen[key] = owner{entry: entry, file: entry.Files[i]}
+				continue
+			}
+			problems = append(problems, &ISSProblem{
+				Line:   entry.Line,
+				Kind:   ISSDuplicateDest,
+				Source: entry.Files[i],
+				Dest:   dest,
+				Detail: fmt.Sprintf("also installed from %s (line %d)", first.file, first.entry.Line),
+			})
+		}
+	}
+	return problems
+}
+
+type issSourceFile struct {
+	// rel 相对 SourceDir 的路径
+	rel string
+	// sub 相对通配符所在目录的路径，recursesubdirs 时保留子目录
+	sub string
+}
+
+// resolveISSSource 解析 Source，支持通配符和 recursesubdirs
+func resolveISSSource(sourceDir string, source string, recurse bool, excludes []string) ([]issSourceFile, error) {
+	source = issSlash(source)
+	full := filepath.FromSlash(source)
+	if !filepath.IsAbs(full) && filepath.VolumeName(full) == "" {
+		full = filepath.Join(sourceDir, full)
+	}
+	rel := func(p string) string {
+		if r, err := filepath.Rel(sourceDir, p); err == nil && !strings.HasPrefix(r, "..") {
+			return filepath.ToSlash(r)
+		}
+		return filepath.ToSlash(p)
+	}
+
+	if !strings.ContainsAny(source, "*?") {
+		info, err := os.Stat(full)
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			return nil, fmt.Errorf("%s is a directory, use %s\\*", source, strings.TrimRight(source, "/"))
+		}
+		return []issSourceFile{{rel: rel(full), sub: filepath.Base(full)}}, nil
+	}
+
+	dir, pattern := filepath.Split(full)
+	dir = filepath.Clean(dir)
+	if strings.ContainsAny(dir, "*?") {
+		return nil, fmt.Errorf("wildcards are only supported in the file name")
+	}
+	var files []issSourceFile
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == dir {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			if p != dir && !recurse {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if ok, _ := filepath.Match(strings.ToLower(pattern), strings.ToLower(d.Name())); !ok {
+			return nil
+		}
+		sub, _ := filepath.Rel(dir, p)
+		sub = filepath.ToSlash(sub)
+		for _, exclude := range excludes {
+			if issExcluded(sub, exclude) {
+				return nil
+			}
+		}
+		files = append(files, issSourceFile{rel: rel(p), sub: sub})
+		return nil
+	})
+	return files, err
+}
+
+// issCompilerBuiltin 返回值中第一个只有编译时才能展开的 ISPP 内置变量
+func issCompilerBuiltin(value string) string {
+	for _, match := range issExpandPattern.FindAllStringSubmatch(value, -1) {
+		for _, name := range ISSCompilerBuiltins {
+			if strings.EqualFold(match[1], name) {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// issExcluded Excludes 中的模式，不含路径时匹配任意层级的名称，以 \ 开头时从通配符所在目录匹配
+func issExcluded(sub string, pattern string) bool {
+	pattern = strings.ToLower(issSlash(strings.TrimSpace(pattern)))
+	if pattern == "" {
+		return false
+	}
+	sub = strings.ToLower(sub)
+	if strings.HasPrefix(pattern, "/") {
+		ok, _ := path.Match(strings.TrimPrefix(pattern, "/"), sub)
+		return ok
+	}
+	parts := strings.Split(sub, "/")
+	n := len(strings.Split(pattern, "/"))
+	// 依次匹配路径末尾的 n 段以及各级目录
+	for i := 0; i+n <= len(parts); i++ {
+		if ok, _ := path.Match(pattern, strings.Join(parts[i:i+n], "/")); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// issExcludes 返回条目的 Excludes 模式
+func issExcludes(f *ISSFile, line *ISSLine) []string {
+	value, ok := line.Param("Excludes")
+	if !ok {
+		return nil
+	}
+	return strings.Split(f.Expand(value), ",")
+}
+
+// issFlags 返回条目的 Flags，小写
+func issFlags(line *ISSLine) map[string]bool {
+	flags := map[string]bool{}
+	value, _ := line.Param("Flags")
+	for _, flag := range strings.Fields(value) {
+		flags[strings.ToLower(flag)] = true
+	}
+	return flags
+}
+
+// issSlash 将 ISS 中的 \ 路径分隔符转换为 /
+func issSlash(p string) string {
+	return strings.ReplaceAll(p, `\`, "/")
+}
@@ -0,0 +1,55 @@
+package obr
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckISSFilesBuiltins(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "dist"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(dir, "dist", "app.exe"), "app")
+	writeTestFile(t, filepath.Join(dir, "setup.iss"), `#define AppName "demo"
+[Setup]
+AppName={#AppName}
+[Files]
+Source: "{#SourcePath}\dist\app.exe"; DestDir: "{app}"
+Source: "{#__DIR__}dist\*"; DestDir: "{app}\copy"
+Source: "{#CompilerPath}\Languages\Default.isl"; DestDir: "{app}"
+Source: "{#Missing}\app.exe"; DestDir: "{app}"
+`)
+
+	f, err := LoadISS(filepath.Join(dir, "setup.iss"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := CheckISSFiles(f, filepath.Join(dir, "setup.iss"))
+	if len(result.Problems) != 1 || result.Problems[0].Kind != ISSUnresolved || result.Problems[0].Line != 8 {
+		t.Fatalf("problems = %v, want only the unresolved {#Missing}", result.Problems)
+	}
+	for i, want := range []int{1, 1} {
+		if got := len(result.Entries[i].Files); got != want {
+			t.Errorf("entry %d files = %v, want %d", i, result.Entries[i].Files, want)
+		}
+	}
+	if got := result.Entries[2].Skipped; got != "{#CompilerPath}" {
+		t.Errorf("entry 2 skipped = %q, want {#CompilerPath}", got)
+	}
+}
+
+func TestISSFileBuiltin(t *testing.T) {
+	f := ParseISS([]byte("#define SourcePath \"override\"\n"))
+	if got := f.Expand("{#SourcePath}"); got != "override" {
+		t.Errorf("#define should take precedence over built-ins, got %q", got)
+	}
+	if got := f.Expand("{#__FILE__}"); got != "{#__FILE__}" {
+		t.Errorf("path built-ins need Path, got %q", got)
+	}
+	f.Path = filepath.Join(os.TempDir(), "setup.iss")
+	if got, want := f.Expand("{#__FILE__}|{#unicode}"), "setup.iss|1"; got != want {
+		t.Errorf("Expand() = %q, want %q", got, want)
+	}
+}
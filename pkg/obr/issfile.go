@@ -0,0 +1,458 @@
+package obr
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"win_helper/pkg/util/fileutil"
+)
+
+// ISSLineKind ISS 脚本中行的类型
+type ISSLineKind int
+
+const (
+	// ISSBlank 空行
+	ISSBlank ISSLineKind = iota
+	// ISSComment 注释行，以 ; 或 // 开头
+	ISSComment
+	// ISSDefine 预处理 #define 行
+	ISSDefine
+	// ISSPreprocessor 其他预处理指令，如 #include、#if
+	ISSPreprocessor
+	// ISSSection 段落头，如 [Setup]
+	ISSSection
+	// ISSDirective 键值对，如 [Setup] 中的 AppName=xxx
+	ISSDirective
+	// ISSEntry 参数条目，如 [Files] 中的 Source: "a.exe"; DestDir: "{app}"
+	ISSEntry
+	// ISSRaw 无需解析的行，如 [Code] 段中的代码
+	ISSRaw
+)
+
+// entrySections 使用 "Name: Value; ..." 参数格式的段落
+var entrySections = map[string]bool{
+	"types":           true,
+	"components":      true,
+	"tasks":           true,
+	"dirs":            true,
+	"files":           true,
+	"icons":           true,
+	"ini":             true,
+	"installdelete":   true,
+	"languages":       true,
+	"registry":        true,
+	"run":             true,
+	"uninstalldelete": true,
+	"uninstallrun":    true,
+}
+
+// ISSParam 参数条目中的一个参数
+type ISSParam struct {
+	Name   string
+	Value  string
+	Quoted bool
+}
+
+// ISSLine ISS 脚本中的一行。未修改的行按原样写回，修改过的行重新生成，保证写回时差异最小。
+type ISSLine struct {
+	// Raw 原始文本，不含换行符
+	Raw string
+	// EOL 原始换行符
+	EOL  string
+	Kind ISSLineKind
+	// Section 所属段落，小写，段落之前的行为空
+	Section string
+
+	// Name 为 define 名称、段落名或键值对的键
+	Name string
+	// Value 为 define 或键值对的值，引号已去除
+	Value string
+	// Quoted 表示 define 的值是否带引号
+	Quoted bool
+	// Params 参数条目的参数
+	Params []*ISSParam
+
+	// valueStart 和 valueEnd 为值在 Raw 中的位置，修改值时只替换这一部分
+	valueStart int
+	valueEnd   int
+	dirty      bool
+}
+
+// ISSFile ISS 脚本模型，保留注释、行顺序、换行符和文件编码
+type ISSFile struct {
+	Lines []*ISSLine
+	// Encoding 原文件编码，写回时保持不变
+	Encoding fileutil.TextEncoding
+	// Path 脚本的绝对路径，用于展开 {#SourcePath} 等 ISPP 内置变量，为空时不展开
+	Path   string
+	hasBOM bool
+}
+
+var (
+	issDefinePattern  = regexp.MustCompile(`^\s*#\s*define\s+(?:(?:public|private|protected)\s+)?([A-Za-z_][A-Za-z0-9_]*)\s*(?:=\s*)?(.*?)\s*$`)
+	issSectionPattern = regexp.MustCompile(`^\[([^\]]+)\]\s*$`)
+	issExpandPattern  = regexp.MustCompile(`\{#\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}`)
+)
+
+// LoadISS 读取并解析 ISS 脚本，支持 UTF-8 (带或不带 BOM)、UTF-16 和 GBK 本地代码页
+func LoadISS(filePath string) (*ISSFile, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %v", err)
+	}
+	text, enc, err := fileutil.DecodeText(data)
+	if err != nil {
+		return nil, err
+	}
+	f := ParseISS(text)
+	f.Encoding = enc
+	f.hasBOM = fileutil.HasBOM(data)
+	if abs, err := filepath.Abs(filePath); err == nil {
+		f.Path = abs
+	}
+	return f, nil
+}
+
+// ParseISS 解析 UTF-8 编码的 ISS 脚本内容
+func ParseISS(data []byte) *ISSFile {
+	f := &ISSFile{Encoding: fileutil.UTF8}
+	section := ""
+	for len(data) > 0 {
+		var raw, eol string
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			raw, data = string(data), nil
+		} else {
+			raw, eol, data = string(data[:i]), "\n", data[i+1:]
+			if strings.HasSuffix(raw, "\r") {
+				raw, eol = raw[:len(raw)-1], "\r\n"
+			}
+		}
+		line := parseISSLine(raw, section)
+		line.EOL = eol
+		if line.Kind == ISSSection {
+			section = line.Section
+		}
+		f.Lines = append(f.Lines, line)
+	}
+	return f
+}
+
+func parseISSLine(raw string, section string) *ISSLine {
+	line := &ISSLine{Raw: raw, Section: section}
+	trimmed := strings.TrimSpace(raw)
+	switch {
+	case trimmed == "":
+		line.Kind = ISSBlank
+	case section == "code" && !issSectionPattern.MatchString(trimmed):
+		line.Kind = ISSRaw
+	case strings.HasPrefix(trimmed, ";") || strings.HasPrefix(trimmed, "//"):
+		line.Kind = ISSComment
+	case strings.HasPrefix(trimmed, "#"):
+		if m := issDefinePattern.FindStringSubmatchIndex(raw); m != nil {
+			line.Kind = ISSDefine
+			line.Name = raw[m[2]:m[3]]
+			line.Value, line.Quoted = unquoteISS(raw[m[4]:m[5]])
+			line.valueStart, line.valueEnd = m[4], m[5]
+		} else {
+			line.Kind = ISSPreprocessor
+		}
+	case issSectionPattern.MatchString(trimmed):
+		m := issSectionPattern.FindStringSubmatch(trimmed)
+		line.Kind = ISSSection
+		line.Name = strings.TrimSpace(m[1])
+		line.Section = strings.ToLower(line.Name)
+	case entrySections[section]:
+		line.Kind = ISSEntry
+		line.Params = parseISSParams(trimmed)
+	default:
+		if i := strings.Index(raw, "="); i >= 0 {
+			line.Kind = ISSDirective
+			line.Name = strings.TrimSpace(raw[:i])
+			line.Value = strings.TrimSpace(raw[i+1:])
+			line.valueStart = i + 1 + (len(raw[i+1:]) - len(strings.TrimLeft(raw[i+1:], " \t")))
+			line.valueEnd = len(strings.TrimRight(raw, " \t"))
+			if line.valueEnd < line.valueStart {
+				line.valueEnd = line.valueStart
+			}
+		} else {
+			line.Kind = ISSRaw
+		}
+	}
+	return line
+}
+
+// unquoteISS 去除双引号，"" 转义为 "
+func unquoteISS(s string) (string, bool) {
+	if len(s) >= 2 && strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) {
+		return strings.ReplaceAll(s[1:len(s)-1], `""`, `"`), true
+	}
+	return s, false
+}
+
+func quoteISS(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// parseISSParams 解析 "Name: Value; Name: "Value"" 格式的参数，引号内的 ; 不作为分隔符
+func parseISSParams(s string) []*ISSParam {
+	var params []*ISSParam
+	var current strings.Builder
+	inQuote := false
+	flush := func() {
+		part := strings.TrimSpace(current.String())
+		current.Reset()
+		if part == "" {
+			return
+		}
+		name, value, _ := strings.Cut(part, ":")
+		value, quoted := unquoteISS(strings.TrimSpace(value))
+		params = append(params, &ISSParam{Name: strings.TrimSpace(name), Value: value, Quoted: quoted})
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"':
+			inQuote = !inQuote
+			current.WriteByte(c)
+		case c == ';' && !inQuote:
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+	return params
+}
+
+// render 生成修改后的行文本
+func (l *ISSLine) render() string {
+	if !l.dirty {
+		return l.Raw
+	}
+	switch l.Kind {
+	case ISSDefine:
+		value := l.Value
+		if l.Quoted {
+			value = quoteISS(value)
+		}
+		if l.Raw != "" {
+			return l.Raw[:l.valueStart] + value + l.Raw[l.valueEnd:]
+		}
+		return fmt.Sprintf("#define %s %s", l.Name, value)
+	case ISSDirective:
+		if l.Raw != "" {
+			return l.Raw[:l.valueStart] + l.Value + l.Raw[l.valueEnd:]
+		}
+		return fmt.Sprintf("%s=%s", l.Name, l.Value)
+	case ISSEntry:
+		parts := make([]string, 0, len(l.Params))
+		for _, p := range l.Params {
+			value := p.Value
+			if p.Quoted {
+				value = quoteISS(value)
+			}
+			parts = append(parts, fmt.Sprintf("%s: %s", p.Name, value))
+		}
+		return strings.Join(parts, "; ")
+	}
+	return l.Raw
+}
+
+// Param 返回参数条目中指定名称的参数值，名称不区分大小写
+func (l *ISSLine) Param(name string) (string, bool) {
+	for _, p := range l.Params {
+		if strings.EqualFold(p.Name, name) {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// SetParam 设置参数条目中的参数值，不存在时追加
+func (l *ISSLine) SetParam(name string, value string) {
+	l.dirty = true
+	for _, p := range l.Params {
+		if strings.EqualFold(p.Name, name) {
+			p.Value = value
+			return
+		}
+	}
+	l.Params = append(l.Params, &ISSParam{Name: name, Value: value, Quoted: true})
+}
+
+// Bytes 将模型写回为文本，未修改的行保持原样
+func (f *ISSFile) Bytes() []byte {
+	var b bytes.Buffer
+	for _, line := range f.Lines {
+		b.WriteString(line.render())
+		b.WriteString(line.EOL)
+	}
+	return b.Bytes()
+}
+
+// eol 返回文件主要使用的换行符
+func (f *ISSFile) eol() string {
+	for _, line := range f.Lines {
+		if line.EOL != "" {
+			return line.EOL
+		}
+	}
+	return "\r\n"
+}
+
+// Defines 返回全部 #define 行
+func (f *ISSFile) Defines() []*ISSLine {
+	var lines []*ISSLine
+	for _, line := range f.Lines {
+		if line.Kind == ISSDefine {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// Define 返回指定名称的 #define 行，不存在时返回 nil
+func (f *ISSFile) Define(name string) *ISSLine {
+	for _, line := range f.Lines {
+		if line.Kind == ISSDefine && line.Name == name {
+			return line
+		}
+	}
+	return nil
+}
+
+// SetDefine 修改 #define 的值并保留原有的引号风格，不存在时添加到最后一个 #define 之后
+func (f *ISSFile) SetDefine(name string, value string) {
+	if line := f.Define(name); line != nil {
+		if line.Value != value {
+			line.Value = value
+			line.dirty = true
+		}
+		return
+	}
+	line := &ISSLine{Kind: ISSDefine, Name: name, Value: value, Quoted: true, EOL: f.eol(), dirty: true}
+	index := 0
+	for i, l := range f.Lines {
+		if l.Kind == ISSDefine {
+			index = i + 1
+		}
+	}
+	f.insert(index, line)
+}
+
+// Section 返回指定段落中的全部行 (不含段落头)，段落名不区分大小写
+func (f *ISSFile) Section(name string) []*ISSLine {
+	var lines []*ISSLine
+	name = strings.ToLower(name)
+	for _, line := range f.Lines {
+		if line.Section == name && line.Kind != ISSSection {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// Entries 返回指定段落中的全部参数条目
+func (f *ISSFile) Entries(section string) []*ISSLine {
+	var lines []*ISSLine
+	for _, line := range f.Section(section) {
+		if line.Kind == ISSEntry {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// Setup 返回 [Setup] 中指定键的行，键不区分大小写，不存在时返回 nil
+func (f *ISSFile) Setup(key string) *ISSLine {
+	for _, line := range f.Section("setup") {
+		if line.Kind == ISSDirective && strings.EqualFold(line.Name, key) {
+			return line
+		}
+	}
+	return nil
+}
+
+// SetSetup 修改 [Setup] 中的键值，不存在时添加到 [Setup] 段落末尾
+func (f *ISSFile) SetSetup(key string, value string) {
+	if line := f.Setup(key); line != nil {
+		if line.Value != value {
+			line.Value = value
+			line.dirty = true
+		}
+		return
+	}
+	line := &ISSLine{Kind: ISSDirective, Section: "setup", Name: key, Value: value, EOL: f.eol(), dirty: true}
+	index := -1
+	for i, l := range f.Lines {
+		if l.Section == "setup" && l.Kind != ISSBlank {
+			index = i + 1
+		}
+	}
+	if index < 0 {
+		// 通过 insert 追加，最后一行没有换行时先补上
+		f.insert(len(f.Lines), &ISSLine{Kind: ISSSection, Section: "setup", Name: "Setup", Raw: "[Setup]", EOL: f.eol()})
+		f.insert(len(f.Lines), line)
+		return
+	}
+	f.insert(index, line)
+}
+
+func (f *ISSFile) insert(index int, line *ISSLine) {
+	if index > 0 && f.Lines[index-1].EOL == "" {
+		f.Lines[index-1].EOL = f.eol()
+	}
+	f.Lines = append(f.Lines, nil)
+	copy(f.Lines[index+1:], f.Lines[index:])
+	f.Lines[index] = line
+}
+
+// Expand 将值中的 {#Name} 替换为 #define 的值，未定义时使用 ISPP 内置变量
+func (f *ISSFile) Expand(value string) string {
+	for i := 0; i < 10 && issExpandPattern.MatchString(value); i++ {
+		value = issExpandPattern.ReplaceAllStringFunc(value, func(s string) string {
+			name := issExpandPattern.FindStringSubmatch(s)[1]
+			if line := f.Define(name); line != nil {
+				return line.Value
+			}
+			if builtin, ok := f.Builtin(name); ok {
+				return builtin
+			}
+			return s
+		})
+	}
+	return value
+}
+
+// ISSCompilerBuiltins 只有编译时才能确定值的 ISPP 内置变量
+var ISSCompilerBuiltins = []string{"CompilerPath", "Ver", "PREPROCVER", "__LINE__"}
+
+// Builtin 返回 ISPP 内置变量的值，名称不区分大小写。
+// SourcePath 等路径变量需要 Path，ISSCompilerBuiltins 中的变量无法展开。
+func (f *ISSFile) Builtin(name string) (string, bool) {
+	switch strings.ToLower(name) {
+	case "ispp_invoked", "windows", "unicode":
+		return "1", true
+	case "newline":
+		return "\r\n", true
+	case "tab":
+		return "\t", true
+	}
+	if f.Path == "" {
+		return "", false
+	}
+	switch strings.ToLower(name) {
+	case "sourcepath", "__dir__":
+		return filepath.Dir(f.Path) + string(filepath.Separator), true
+	case "__file__":
+		return filepath.Base(f.Path), true
+	case "__pathfilename__":
+		return f.Path, true
+	}
+	return "", false
+}
@@ -0,0 +1,12 @@
+package obr
+
+import "testing"
+
+func TestISSFileSetSetupNoTrailingEOL(t *testing.T) {
+	f := ParseISS([]byte("#define MyAppVersion \"1.0.0\""))
+	f.SetSetup("AppVersion", "{#MyAppVersion}")
+	want := "#define MyAppVersion \"1.0.0\"\r\n[Setup]\r\nAppVersion={#MyAppVersion}\r\n"
+	if got := string(f.Bytes()); got != want {
+		t.Errorf("Bytes() = %q, want %q", got, want)
+	}
+}
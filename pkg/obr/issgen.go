@@ -0,0 +1,293 @@
+package obr
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+
+	"win_helper/pkg/winserver"
+	"win_helper/templates"
+)
+
+// ISSIcon 开始菜单快捷方式
+type ISSIcon struct {
+	Name       string `json:"name" yaml:"name"`
+	Filename   string `json:"filename" yaml:"filename"`
+	Parameters string `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+}
+
+// ISSRun 安装完成后执行的程序
+type ISSRun struct {
+	Filename    string `json:"filename" yaml:"filename"`
+	Parameters  string `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Flags       string `json:"flags,omitempty" yaml:"flags,omitempty"`
+}
+
+// ISSProject 生成 Inno Setup 脚本所需的安装包信息
+type ISSProject struct {
+	Name               string `json:"name" yaml:"name"`
+	Version            string `json:"version" yaml:"version"`
+	Publisher          string `json:"publisher,omitempty" yaml:"publisher,omitempty"`
+	URL                string `json:"url,omitempty" yaml:"url,omitempty"`
+	AppId              string `json:"appid,omitempty" yaml:"appid,omitempty"`
+	OutputDir          string `json:"outputdir,omitempty" yaml:"outputdir,omitempty"`
+	OutputBaseFilename string `json:"outputbasefilename,omitempty" yaml:"outputbasefilename,omitempty"`
+	DefaultDir         string `json:"defaultdir,omitempty" yaml:"defaultdir,omitempty"`
+	// Dist 需要打包的目录，其中的文件全部写入 [Files]
+	Dist string `json:"dist" yaml:"dist"`
+	// Exe 主程序，相对于 Dist，会生成开始菜单快捷方式和安装后运行项
+	Exe   string     `json:"exe,omitempty" yaml:"exe,omitempty"`
+	Icons []*ISSIcon `json:"icons,omitempty" yaml:"icons,omitempty"`
+	Run   []*ISSRun  `json:"run,omitempty" yaml:"run,omitempty"`
+	// ServiceManifest 服务清单，服务文件生成到 Dist 中并在安装时注册
+	ServiceManifest string `json:"services,omitempty" yaml:"services,omitempty"`
+}
+
+// LoadISSProject 读取 yaml 或 json 格式的安装包信息
+func LoadISSProject(filename string) (*ISSProject, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+	p := &ISSProject{}
+	if err := yaml.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("解析文件 %s 失败: %v", filename, err)
+	}
+	return p, nil
+}
+
+// issFile [Files] 条目
+type issFile struct {
+	Source  string
+	DestDir string
+}
+
+// issService 需要注册的服务
+type issService struct {
+	Id         string
+	Name       string
+	Executable string
+}
+
+// setDefaults 填充未设置的字段
+func (p *ISSProject) setDefaults() {
+	if p.AppId == "" {
+		p.AppId = strings.ToUpper(uuid.NewString())
+	}
+	p.AppId = strings.Trim(p.AppId, "{}")
+	if p.OutputDir == "" {
+		p.OutputDir = "output"
+	}
+	if p.OutputBaseFilename == "" {
+		p.OutputBaseFilename = "{#MyAppName}-{#MyAppVersion}-setup"
+	}
+	if p.DefaultDir == "" {
+		p.DefaultDir = `{autopf}\{#MyAppName}`
+	}
+	if p.Exe != "" {
+		p.Icons = append([]*ISSIcon{{Name: "{#MyAppName}", Filename: `{app}\{#MyAppExeName}`}}, p.Icons...)
+		p.Run = append(p.Run, &ISSRun{
+			Filename:    `{app}\{#MyAppExeName}`,
+			Description: "{cm:LaunchProgram,{#StringChange(MyAppName, '&', '&&')}}",
+			Flags:       "nowait postinstall skipifsilent",
+		})
+	}
+	for _, run := range p.Run {
+		if run.Flags == "" {
+			run.Flags = "nowait postinstall skipifsilent"
+		}
+		if run.Description == "" {
+			run.Description = run.Filename
+		}
+	}
+}
+
+func (p *ISSProject) validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+	if p.Version == "" {
+		return fmt.Errorf("missing version")
+	}
+	if p.Dist == "" {
+		return fmt.Errorf("missing dist")
+	}
+	return nil
+}
+
+// resolveServices 读取服务清单，服务文件的生成目录为 Dist
+func (p *ISSProject) resolveServices() ([]*winserver.Server, error) {
+	if p.ServiceManifest == "" {
+		return nil, nil
+	}
+	manifest, err := winserver.LoadManifest(p.ServiceManifest)
+	if err != nil {
+		return nil, err
+	}
+	servers, err := manifest.ResolveAll("")
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range servers {
+		if err := s.Apply(winserver.WithBasePath(p.Dist), winserver.WithSForce(true)); err != nil {
+			return nil, err
+		}
+	}
+	return servers, nil
+}
+
+// GenerateServices 将服务清单中的服务文件生成到 Dist 目录，没有服务清单时不做任何事
+func (p *ISSProject) GenerateServices() error {
+	if p.Dist == "" {
+		return fmt.Errorf("missing dist")
+	}
+	servers, err := p.resolveServices()
+	if err != nil {
+		return err
+	}
+	for _, s := range servers {
+		if err := s.Generate(); err != nil {
+			return fmt.Errorf("生成服务 %s 失败: %v", s.SName, err)
+		}
+	}
+	return nil
+}
+
+// services 返回需要注册的服务和 GenerateServices 会生成的文件 (相对于 Dist)，不生成服务文件
+func (p *ISSProject) services() ([]*issService, []string, error) {
+	servers, err := p.resolveServices()
+	if err != nil {
+		return nil, nil, err
+	}
+	var services []*issService
+	var files []string
+	for _, s := range servers {
+		// 服务安装后的 id 以生成的配置为准
+		serverXML, err := s.BuildServerXML()
+		if err != nil {
+			return nil, nil, err
+		}
+		scripts, err := s.FirewallScripts()
+		if err != nil {
+			return nil, nil, err
+		}
+		service := &issService{Id: serverXML.Id, Name: s.SName, Executable: fmt.Sprintf("%s-server.exe", s.SName)}
+		services = append(services, service)
+		files = append(files, service.Executable, fmt.Sprintf("%s-server.xml", s.SName))
+		for name := range scripts {
+			files = append(files, name)
+		}
+	}
+	return services, files, nil
+}
+
+// scanFiles 扫描 Dist 目录，Source 为相对于脚本所在目录的路径。
+// extra 为还未生成到 Dist 中的文件 (相对于 Dist)，已存在时不重复添加
+func (p *ISSProject) scanFiles(issPath string, extra ...string) ([]*issFile, error) {
+	issDir, err := filepath.Abs(filepath.Dir(issPath))
+	if err != nil {
+		return nil, err
+	}
+	dist, err := filepath.Abs(p.Dist)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	err = filepath.WalkDir(dist, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("扫描目录 %s 失败: %v", p.Dist, err)
+	}
+	for _, name := range extra {
+		if path := filepath.Join(dist, name); !containsPath(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+	// 与服务文件是否已经生成无关，输出顺序保持一致
+	sort.Strings(paths)
+
+	files := make([]*issFile, 0, len(paths))
+	for _, path := range paths {
+		source, err := filepath.Rel(issDir, path)
+		if err != nil {
+			source = path
+		}
+		destDir := "{app}"
+		if rel, _ := filepath.Rel(dist, filepath.Dir(path)); rel != "." {
+			destDir += `\` + rel
+		}
+		files = append(files, &issFile{
+			Source:  strings.ReplaceAll(source, "/", `\`),
+			DestDir: strings.ReplaceAll(destDir, "/", `\`),
+		})
+	}
+	return files, nil
+}
+
+// issEscape 转义双引号中的值
+func issEscape(s string) string {
+	return strings.ReplaceAll(s, `"`, `""`)
+}
+
+// Generate 生成 Inno Setup 脚本内容，issPath 用于计算文件的相对路径。
+// 不写入任何文件，服务文件即使还没有生成也会写入 [Files]，需要另外调用 GenerateServices 生成。
+func (p *ISSProject) Generate(issPath string) ([]byte, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	p.setDefaults()
+	services, serviceFiles, err := p.services()
+	if err != nil {
+		return nil, err
+	}
+	files, err := p.scanFiles(issPath, serviceFiles...)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("dist %s has no files", p.Dist)
+	}
+
+	template, err := templates.GetTemplateByName("iss/setup.iss.tpl")
+	if err != nil {
+		return nil, err
+	}
+	tpl, err := pongo2.FromBytes(template)
+	if err != nil {
+		return nil, err
+	}
+	escaped := *p
+	escaped.Name = issEscape(p.Name)
+	escaped.Version = issEscape(p.Version)
+	escaped.Publisher = issEscape(p.Publisher)
+	escaped.URL = issEscape(p.URL)
+	escaped.Exe = issEscape(p.Exe)
+	out, err := tpl.ExecuteBytes(pongo2.Context{
+		"project":  &escaped,
+		"appId":    "{{" + p.AppId + "}",
+		"files":    files,
+		"services": services,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Inno Setup 脚本使用 CRLF 换行，带 BOM 的 UTF-8 保证中文正常显示
+	text := strings.ReplaceAll(string(out), "\r\n", "\n")
+	text = "\ufeff" + strings.ReplaceAll(text, "\n", "\r\n")
+	return []byte(text), nil
+}
@@ -0,0 +1,574 @@
+package obr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gookit/goutil/fsutil"
+
+	"win_helper/pkg/util/command"
+	"win_helper/pkg/util/fileutil"
+)
+
+// 内置步骤
+const (
+	StepBump        = "bump"
+	StepSyncVersion = "sync-version"
+	StepChangelog   = "changelog"
+	StepCommit      = "commit"
+	StepTag         = "tag"
+	StepPush        = "push"
+	StepChecksum    = "checksum"
+)
+
+// PipelineStateFile 发布流水线状态文件，失败后用于 --resume
+const PipelineStateFile = ".obr/release-state.json"
+
+// DefaultPipeline 未配置 pipeline 时使用的步骤
+func DefaultPipeline() []*PipelineStep {
+	return []*PipelineStep{
+		{Uses: StepBump},
+		{Uses: StepChangelog},
+		{Uses: StepCommit},
+		{Uses: StepTag},
+		{Uses: StepPush},
+	}
+}
+
+// PipelineStep 流水线中的一步，Uses 为内置步骤，Run 为通过 shell 执行的命令，二者只能设置一个
+//
+//	bump:         with target (app|iss，默认 app)、version (操作符或 auto，默认 +)
+//	sync-version: 按 sync-version 配置同步版本号
+//	changelog:    生成更新日志，with all: "true" 包含其他类型的提交
+//	commit:       提交流水线修改的文件
+//	tag:          创建标签，with message: changelog 使用更新日志作为标签说明
+//	push:         推送标签
+//	checksum:     为发布目录写入 SHA256SUMS 和清单，with dir (默认为 ISS 的 OutputDir)、installer-only: "true"
+type PipelineStep struct {
+	Name string            `mapstructure:"name" yaml:"name,omitempty" json:"name,omitempty"`
+	Uses string            `mapstructure:"uses" yaml:"uses,omitempty" json:"uses,omitempty"`
+	Run  string            `mapstructure:"run" yaml:"run,omitempty" json:"run,omitempty"`
+	With map[string]string `mapstructure:"with" yaml:"with,omitempty" json:"with,omitempty"`
+}
+
+// Title 返回步骤的显示名称
+func (s *PipelineStep) Title() string {
+	switch {
+	case s.Name != "":
+		return s.Name
+	case s.Uses != "":
+		return s.Uses
+	}
+	return "run: " + s.Run
+}
+
+func (s *PipelineStep) with(key string, def string) string {
+	if v := s.With[key]; v != "" {
+		return v
+	}
+	return def
+}
+
+// Validate 检查步骤定义
+func (s *PipelineStep) Validate() error {
+	if (s.Uses == "") == (s.Run == "") {
+		return fmt.Errorf("step %s: exactly one of uses or run is required", s.Title())
+	}
+	switch s.Uses {
+	case "", StepBump, StepSyncVersion, StepChangelog, StepCommit, StepTag, StepPush, StepChecksum:
+	default:
+		return fmt.Errorf("step %s: unknown built-in step %s", s.Title(), s.Uses)
+	}
+	if s.Uses == StepBump {
+		switch t := s.with("target", "app"); t {
+		case "app", "iss":
+		default:
+			return fmt.Errorf("step %s: unknown target %s", s.Title(), t)
+		}
+	}
+	return nil
+}
+
+// PipelineState 流水线的执行状态，每步完成后保存，失败后从 Next 继续
+type PipelineState struct {
+	Steps      []string  `json:"steps"`
+	Next       int       `json:"next"`
+	OldVersion string    `json:"old_version,omitempty"`
+	NewVersion string    `json:"new_version,omitempty"`
+	Tag        string    `json:"tag,omitempty"`
+	Files      []string  `json:"files,omitempty"`
+	Changelog  string    `json:"changelog,omitempty"`
+	Commit     string    `json:"commit,omitempty"`
+	Pushed     bool      `json:"pushed"`
+	Error      string    `json:"error,omitempty"`
+	Started    time.Time `json:"started"`
+}
+
+// LoadPipelineState 读取流水线状态，文件不存在时返回 nil
+func LoadPipelineState(filename string) (*PipelineState, error) {
+	if !fsutil.FileExist(filename) {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+	state := &PipelineState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("解析文件 %s 失败: %v", filename, err)
+	}
+	return state, nil
+}
+
+// Save 保存流水线状态
+func (s *PipelineState) Save(filename string) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteFileAtomic(filename, append(data, '\n'), 0o644)
+}
+
+// Pipeline 按步骤执行发布。失败时保存状态，修复后从失败的步骤继续，不回滚已完成的步骤。
+type Pipeline struct {
+	Steps  []*PipelineStep
+	Config *Config
+	Git    Git
+	// Dir 配置文件所在目录，sync-version 的相对路径基于该目录
+	Dir       string
+	DryRun    bool
+	SkipCheck bool
+	// StateFile 为空时不保存状态
+	StateFile string
+	Out       io.Writer
+	State     *PipelineState
+	// resumed 从保存的状态继续，开始前的检查已经在第一次执行时完成
+	resumed bool
+}
+
+// NewPipeline 创建流水线，state 为 nil 时从头开始
+func NewPipeline(config *Config, git Git, state *PipelineState) (*Pipeline, error) {
+	steps := config.Pipeline
+	if len(steps) == 0 {
+		steps = DefaultPipeline()
+	}
+	p := &Pipeline{Steps: steps, Config: config, Git: git, Out: os.Stdout, State: state, resumed: state != nil}
+	var titles []string
+	for _, step := range steps {
+		if err := step.Validate(); err != nil {
+			return nil, err
+		}
+		titles = append(titles, step.Title())
+	}
+	if state == nil {
+		p.State = &PipelineState{Steps: titles, Started: time.Now()}
+	} else if strings.Join(state.Steps, "\x00") != strings.Join(titles, "\x00") {
+		return nil, fmt.Errorf("pipeline changed since the failed run, start again without --resume")
+	}
+	return p, nil
+}
+
+func (p *Pipeline) printf(format string, a ...any) {
+	if p.Out != nil {
+		_, _ = fmt.Fprintf(p.Out, format, a...)
+	}
+}
+
+// Plan 返回步骤列表，已完成的步骤标记为 done
+func (p *Pipeline) Plan() string {
+	var b strings.Builder
+	for i, step := range p.Steps {
+		mark := " "
+		if i < p.State.Next {
+			mark = "done"
+		}
+		detail := ""
+		switch {
+		case step.Run != "":
+			detail = step.Run
+		case len(step.With) > 0:
+			var with []string
+			for k, v := range step.With {
+				with = append(with, k+"="+v)
+			}
+			detail = strings.Join(with, " ")
+		}
+		_, _ = fmt.Fprintf(&b, "%2d. %-4s %-16s %s\n", i+1, mark, step.Title(), detail)
+	}
+	return b.String()
+}
+
+// Run 从 State.Next 开始执行，失败时保存状态并返回错误，全部完成后删除状态文件
+func (p *Pipeline) Run() error {
+	if !p.resumed && !p.SkipCheck {
+		if err := p.preflight(); err != nil {
+			return err
+		}
+	}
+	total := len(p.Steps)
+	for i := p.State.Next; i < total; i++ {
+		step := p.Steps[i]
+		p.printf("[%d/%d] %s\n", i+1, total, step.Title())
+		if err := p.runStep(step); err != nil {
+			p.State.Next = i
+			p.State.Error = err.Error()
+			if saveErr := p.saveState(); saveErr != nil {
+				p.printf("warning: save state failed: %v\n", saveErr)
+			}
+			return fmt.Errorf("step %d (%s) failed: %v", i+1, step.Title(), err)
+		}
+		p.State.Next = i + 1
+		p.State.Error = ""
+		if err := p.saveState(); err != nil {
+			return err
+		}
+	}
+	if p.StateFile != "" && !p.DryRun {
+		_ = os.Remove(p.StateFile)
+	}
+	return nil
+}
+
+func (p *Pipeline) saveState() error {
+	if p.StateFile == "" || p.DryRun {
+		return nil
+	}
+	return p.State.Save(p.StateFile)
+}
+
+// preflight 开始前检查工作区和分支，标签和版本号在 bump 中检查
+func (p *Pipeline) preflight() error {
+	check := &Preflight{Git: p.Git, Branches: p.Config.Branches}
+	if releaseFile, err := p.Config.ReleasePath(p.Dir); err == nil {
+		check.Files = []string{releaseFile}
+	}
+	// 上一次失败后使用 --restart 时状态文件可能还在
+	if p.StateFile != "" {
+		if stateFile, err := filepath.Abs(p.StateFile); err == nil {
+			check.Files = append(check.Files, stateFile)
+		}
+	}
+	var errs []string
+	for _, err := range []error{check.CheckClean(), check.CheckBranch()} {
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("preflight: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (p *Pipeline) hasStep(uses string) bool {
+	for _, step := range p.Steps {
+		if step.Uses == uses {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Pipeline) addFile(filename string) {
+	if abs, err := filepath.Abs(filename); err == nil {
+		filename = abs
+	}
+	if !containsPath(p.State.Files, filename) {
+		p.State.Files = append(p.State.Files, filename)
+	}
+}
+
+func (p *Pipeline) requireVersion() error {
+	if p.State.NewVersion == "" {
+		return fmt.Errorf("no version, add a bump step before this step")
+	}
+	return nil
+}
+
+func (p *Pipeline) runStep(step *PipelineStep) error {
+	if step.Run != "" {
+		return p.runCommand(step.Run)
+	}
+	switch step.Uses {
+	case StepBump:
+		return p.bump(step)
+	case StepSyncVersion:
+		return p.syncVersion()
+	case StepChangelog:
+		return p.changelog(step)
+	case StepCommit:
+		return p.commit()
+	case StepTag:
+		return p.tag(step)
+	case StepPush:
+		if err := p.Git.PushTags(); err != nil {
+			return err
+		}
+		p.State.Pushed = true
+	case StepChecksum:
+		return p.checksum(step)
+	}
+	return nil
+}
+
+func (p *Pipeline) runCommand(line string) error {
+	if p.DryRun {
+		p.printf("[dry-run] run: %s\n", line)
+		return nil
+	}
+	env := HookEnv(p.State.OldVersion, p.State.NewVersion, p.State.Tag)
+	out, err := command.RunCommandEnv(p.Dir, env, command.ShellArgs(line)...)
+	p.printf("%s", out)
+	if out != "" && !strings.HasSuffix(out, "\n") {
+		p.printf("\n")
+	}
+	return err
+}
+
+func (p *Pipeline) bump(step *PipelineStep) error {
+	target := step.with("target", "app")
+	var filename, current, fallback string
+	var err error
+	switch target {
+	case "iss":
+		if filename = p.Config.IssPath; filename == "" {
+			return fmt.Errorf("iss-path is not set")
+		}
+		var v *ISSValue
+		if v, err = GetISSValue(filename, p.Config.VersionKey); err != nil {
+			return err
+		}
+		current, fallback = v.Value, ISSTagTemplate
+	default:
+		filename = p.Config.VersionFile
+		if current, err = GetAppVersion(filename); err != nil {
+			return err
+		}
+		fallback = AppTagTemplate
+	}
+	next, err := NextVersion(p.Git, current, step.with("version", "+"), p.Config.TagTemplate, p.Out)
+	if err != nil {
+		return err
+	}
+	tag, err := p.Config.Tag(next, fallback)
+	if err != nil {
+		return err
+	}
+	if !p.SkipCheck {
+		check := &Preflight{Git: p.Git, Version: next, TagTemplate: p.Config.TagTemplate}
+		if p.hasStep(StepTag) {
+			check.Tag = tag
+			check.Remote = p.hasStep(StepPush)
+		}
+		for _, err := range []error{check.CheckTag(), check.CheckVersion()} {
+			if err != nil {
+				return err
+			}
+		}
+	}
+	p.printf("update version %s ---> %s\n", current, next)
+	if !p.DryRun {
+		if target == "iss" {
+			err = SaveISSVersionKey(next, filename, p.Config.VersionKey)
+		} else {
+			err = SaveAppVersion(next, filename)
+		}
+		if err != nil {
+			return fmt.Errorf("error saving version: %v", err)
+		}
+	}
+	p.State.OldVersion, p.State.NewVersion, p.State.Tag = current, next, tag
+	p.addFile(filename)
+	return nil
+}
+
+func (p *Pipeline) syncVersion() error {
+	if err := p.requireVersion(); err != nil {
+		return err
+	}
+	sync := p.Config.SyncVersion
+	if sync == nil {
+		return fmt.Errorf("missing sync-version section")
+	}
+	if err := sync.Validate(); err != nil {
+		return err
+	}
+	targets := append([]*SyncTarget{sync.Source}, sync.Targets...)
+	results := (&SyncConfig{Targets: targets}).Sync(p.Dir, p.State.NewVersion, p.DryRun)
+	var failed []string
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			failed = append(failed, r.Err.Error())
+		case r.Changed():
+			p.printf("%s: %s -> %s\n", r.Target, r.OldVersion, r.NewVersion)
+			p.addFile(resolveSyncPath(p.Dir, r.Target.Path))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+func (p *Pipeline) changelog(step *PipelineStep) error {
+	if err := p.requireVersion(); err != nil {
+		return err
+	}
+	from, err := p.Git.LastTag("HEAD", TagPatterns(p.Config.TagTemplate)...)
+	if err != nil {
+		return err
+	}
+	commits, err := p.Git.Log(from, "HEAD")
+	if err != nil {
+		return err
+	}
+	changelog := NewChangelog(p.State.NewVersion, time.Now().Format("2006-01-02"), ParseConventionalCommits(commits), step.with("all", "") == "true")
+	content, err := changelog.Render()
+	if err != nil {
+		return err
+	}
+	if p.DryRun {
+		p.printf("[dry-run] write %s\n%s", p.Config.ChangelogFile, content)
+	} else if err := PrependChangelog(p.Config.ChangelogFile, content); err != nil {
+		return err
+	}
+	p.State.Changelog = content
+	p.addFile(p.Config.ChangelogFile)
+	return nil
+}
+
+// checksum 为发布目录写入校验文件，配置了 ISS 时按 OutputBaseFilename 识别安装包。
+// 发布目录通常不在版本库中，校验文件不会被提交
+func (p *Pipeline) checksum(step *PipelineStep) error {
+	dir := step.with("dir", "")
+	if dir != "" {
+		dir = resolveSyncPath(p.Dir, dir)
+	}
+	var naming *ArtifactNaming
+	version := p.State.NewVersion
+	if p.Config.IssPath != "" {
+		f, err := LoadISS(p.Config.IssPath)
+		if err != nil {
+			return err
+		}
+		naming = ISSNaming(f, p.Config.VersionKey)
+		if step.with("dir", "") == "" {
+			dir = f.OutputDir(p.Config.IssPath)
+		}
+		if version == "" {
+			version = naming.Version
+		}
+	}
+	if dir == "" {
+		return fmt.Errorf("checksum: with dir is required when iss-path is not set")
+	}
+	manifest, err := NewArtifactManifest(dir, naming, version, step.with("installer-only", "") == "true", ChecksumFile, ManifestFile)
+	if err != nil {
+		return err
+	}
+	if naming != nil && !manifest.HasInstaller(naming.InstallerName()) {
+		return fmt.Errorf("installer %s not found in %s", naming.InstallerName(), dir)
+	}
+	if p.DryRun {
+		p.printf("[dry-run] write %s\n", filepath.Join(dir, ChecksumFile))
+		return nil
+	}
+	if err := WriteChecksums(dir, ChecksumFile, ManifestFile, manifest); err != nil {
+		return err
+	}
+	p.printf("%d file(s) written to %s\n", len(manifest.Files), filepath.Join(dir, ChecksumFile))
+	return nil
+}
+
+func (p *Pipeline) commit() error {
+	if err := p.requireVersion(); err != nil {
+		return err
+	}
+	message, _, err := p.Config.Messages(p.State.OldVersion, p.State.NewVersion, p.State.Tag)
+	if err != nil {
+		return err
+	}
+	files := append([]string{}, p.State.Files...)
+	releaseFile, err := p.Config.ReleasePath(p.Dir)
+	if err != nil {
+		return err
+	}
+	// 之前失败时追加的发布记录随本次发布提交，本次的记录在 Run 结束后写入
+	if fsutil.FileExist(releaseFile) && !containsPath(files, releaseFile) {
+		files = append(files, releaseFile)
+	}
+	if err := CommitChange(p.Git, message, files...); err != nil {
+		return err
+	}
+	p.State.Commit, err = p.Git.Head()
+	return err
+}
+
+func (p *Pipeline) tag(step *PipelineStep) error {
+	if err := p.requireVersion(); err != nil {
+		return err
+	}
+	_, message, err := p.Config.Messages(p.State.OldVersion, p.State.NewVersion, p.State.Tag)
+	if err != nil {
+		return err
+	}
+	if step.with("message", "") == "changelog" && p.State.Changelog != "" {
+		message = p.State.Changelog
+	}
+	return p.Git.Tag(p.State.Tag, message)
+}
+
+// Record 生成发布记录，err 为 Run 的结果，Steps 为已完成的步骤
+func (p *Pipeline) Record(dir string, err error) *ReleaseRecord {
+	rec := &ReleaseRecord{
+		Command:    "release",
+		OldVersion: p.State.OldVersion,
+		NewVersion: p.State.NewVersion,
+		Tag:        p.State.Tag,
+		Commit:     p.State.Commit,
+		Pushed:     p.State.Pushed,
+		Status:     ReleaseStatusBumped,
+		Files:      []string{},
+		Steps:      []string{},
+		User:       currentUser(),
+		Started:    p.State.Started,
+		Finished:   time.Now(),
+	}
+	for _, f := range p.State.Files {
+		if rel, relErr := filepath.Rel(dir, f); relErr == nil {
+			f = filepath.ToSlash(rel)
+		}
+		rec.Files = append(rec.Files, f)
+	}
+	rec.Steps = append(rec.Steps, p.State.Steps[:p.State.Next]...)
+	switch {
+	case err != nil:
+		rec.Status = ReleaseStatusFailed
+		rec.Error = err.Error()
+	case p.State.Pushed:
+		rec.Status = ReleaseStatusReleased
+	}
+	return rec
+}
+
+// WriteRecord 在 Run 结束后追加发布记录，err 为 Run 的结果。
+// 成功且已经提交时记录单独提交，失败时记录留在工作区，--resume 完成后随发布提交
+func (p *Pipeline) WriteRecord(releaseFile string, err error) error {
+	if p.DryRun {
+		return nil
+	}
+	rec := p.Record(filepath.Dir(releaseFile), err)
+	if err == nil && p.State.Commit != "" {
+		return CommitReleaseRecord(p.Git, releaseFile, rec)
+	}
+	return AppendReleaseRecord(releaseFile, rec)
+}
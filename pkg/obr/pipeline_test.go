@@ -0,0 +1,227 @@
+package obr
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestPipelineRepo 创建提交了 VERSION 1.0.0 的仓库和发布配置
+func newTestPipelineRepo(t *testing.T, steps ...*PipelineStep) (string, string, *ExecGit, *Config) {
+	t.Helper()
+	repo, remote := newTestRepo(t)
+	git, _ := newTestExecGit(repo)
+	versionFile := filepath.Join(repo, "VERSION")
+	writeTestFile(t, versionFile, "1.0.0\n")
+	if err := CommitChange(git, "init", versionFile); err != nil {
+		t.Fatal(err)
+	}
+	branch, _ := git.Branch()
+
+	config := DefaultConfig()
+	config.VersionFile = versionFile
+	config.ChangelogFile = filepath.Join(repo, ChangelogFile)
+	config.Branches = []string{branch}
+	config.Pipeline = steps
+	return repo, remote, git, config
+}
+
+// runTestPipeline 与 obr release 相同: 执行流水线后写入发布记录
+func runTestPipeline(t *testing.T, repo string, git Git, config *Config, state *PipelineState) (*Pipeline, error) {
+	t.Helper()
+	p, err := NewPipeline(config, git, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Dir, p.StateFile, p.Out = repo, filepath.Join(repo, PipelineStateFile), io.Discard
+	err = p.Run()
+	if recordErr := p.WriteRecord(filepath.Join(repo, ReleaseFile), err); recordErr != nil {
+		t.Fatal(recordErr)
+	}
+	return p, err
+}
+
+func loadTestState(t *testing.T, repo string) *PipelineState {
+	t.Helper()
+	state, err := LoadPipelineState(filepath.Join(repo, PipelineStateFile))
+	if err != nil || state == nil {
+		t.Fatalf("LoadPipelineState() = %+v, %v", state, err)
+	}
+	return state
+}
+
+func loadTestRecords(t *testing.T, repo string) []*ReleaseRecord {
+	t.Helper()
+	records, err := LoadReleaseRecords(filepath.Join(repo, ReleaseFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+func TestPipelineResumeFirstStep(t *testing.T) {
+	ok := filepath.Join(t.TempDir(), "ok")
+	repo, _, git, config := newTestPipelineRepo(t, &PipelineStep{Run: "test -f " + ok}, &PipelineStep{Uses: StepBump}, &PipelineStep{Uses: StepCommit})
+	if _, err := runTestPipeline(t, repo, git, config, nil); err == nil {
+		t.Fatal("Run() succeeded without ok")
+	}
+
+	// 修复后继续，状态文件不应被开始前的检查当作未提交的改动
+	writeTestFile(t, ok, "")
+	state := loadTestState(t, repo)
+	if state.Next != 0 {
+		t.Fatalf("state.Next = %d, want 0", state.Next)
+	}
+	if _, err := runTestPipeline(t, repo, git, config, state); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if got, _ := GetAppVersion(config.VersionFile); got != "1.0.1" {
+		t.Fatalf("version = %s, want 1.0.1", got)
+	}
+	// 失败的记录随发布提交，本次的记录单独提交
+	if got := runGit(t, repo, "show", "--name-only", "--format=", "HEAD^"); got != "VERSION\nrelease.json" {
+		t.Fatalf("committed files:\n%s", got)
+	}
+	if got := runGit(t, repo, "log", "-1", "--format=%s"); got != "record release v1.0.1" {
+		t.Fatalf("HEAD subject = %q", got)
+	}
+	records := loadTestRecords(t, repo)
+	if len(records) != 2 || records[0].Status != ReleaseStatusFailed || records[1].Status != ReleaseStatusBumped {
+		t.Fatalf("records = %+v", records)
+	}
+}
+
+func TestPipelineRestart(t *testing.T) {
+	ok := filepath.Join(t.TempDir(), "ok")
+	repo, _, git, config := newTestPipelineRepo(t, &PipelineStep{Run: "test -f " + ok}, &PipelineStep{Uses: StepBump}, &PipelineStep{Uses: StepCommit})
+	if _, err := runTestPipeline(t, repo, git, config, nil); err == nil {
+		t.Fatal("Run() succeeded without ok")
+	}
+	failed := loadTestState(t, repo)
+
+	// --restart 丢弃状态从头开始，留下的状态文件和失败的记录不影响开始前的检查
+	writeTestFile(t, ok, "")
+	p, err := runTestPipeline(t, repo, git, config, nil)
+	if err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	if !p.State.Started.After(failed.Started) {
+		t.Errorf("Started = %v, want a new start after %v", p.State.Started, failed.Started)
+	}
+	if _, err := os.Stat(filepath.Join(repo, PipelineStateFile)); !os.IsNotExist(err) {
+		t.Errorf("state file not removed: %v", err)
+	}
+	if got, _ := GetAppVersion(config.VersionFile); got != "1.0.1" {
+		t.Fatalf("version = %s, want 1.0.1", got)
+	}
+}
+
+func TestPipelineChangedSinceFailure(t *testing.T) {
+	ok := filepath.Join(t.TempDir(), "ok")
+	repo, _, git, config := newTestPipelineRepo(t, &PipelineStep{Run: "test -f " + ok}, &PipelineStep{Uses: StepBump})
+	if _, err := runTestPipeline(t, repo, git, config, nil); err == nil {
+		t.Fatal("Run() succeeded without ok")
+	}
+	state := loadTestState(t, repo)
+
+	config.Pipeline = append(config.Pipeline, &PipelineStep{Uses: StepCommit})
+	if _, err := NewPipeline(config, git, state); err == nil || !strings.Contains(err.Error(), "pipeline changed") {
+		t.Fatalf("NewPipeline() error = %v, want pipeline changed", err)
+	}
+}
+
+func TestPipelineRecord(t *testing.T) {
+	dist := t.TempDir()
+	writeTestFile(t, filepath.Join(dist, "app.zip"), "app")
+	ok := filepath.Join(t.TempDir(), "ok")
+	repo, remote, git, config := newTestPipelineRepo(t,
+		&PipelineStep{Uses: StepBump},
+		&PipelineStep{Uses: StepCommit},
+		&PipelineStep{Name: "check", Run: "test -f " + ok},
+		&PipelineStep{Uses: StepTag},
+		&PipelineStep{Uses: StepPush},
+		&PipelineStep{Uses: StepChecksum, With: map[string]string{"dir": dist}},
+	)
+
+	// 提交之后失败: 记录只包含已完成的步骤，留在工作区
+	p, err := runTestPipeline(t, repo, git, config, nil)
+	if err == nil {
+		t.Fatal("Run() succeeded without ok")
+	}
+	records := loadTestRecords(t, repo)
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+	failed := records[0]
+	if failed.Status != ReleaseStatusFailed || failed.Pushed || failed.Error == "" {
+		t.Errorf("failed record = %+v", failed)
+	}
+	if failed.Commit != p.State.Commit || failed.Commit == "" {
+		t.Errorf("failed record commit = %q, want %q", failed.Commit, p.State.Commit)
+	}
+	if got := strings.Join(failed.Steps, ","); got != "bump,commit" {
+		t.Errorf("failed record steps = %s", got)
+	}
+	if status, _ := git.Status(); !containsPath(status, filepath.Join(repo, ReleaseFile)) {
+		t.Errorf("failed record should stay uncommitted, status %v", status)
+	}
+
+	writeTestFile(t, ok, "")
+	if _, err := runTestPipeline(t, repo, git, config, loadTestState(t, repo)); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	records = loadTestRecords(t, repo)
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	rec := records[1]
+	if want := runGit(t, remote, "rev-parse", "v1.0.1^{}"); rec.Commit != want {
+		t.Errorf("Commit = %q, want the pushed tag commit %q", rec.Commit, want)
+	}
+	if rec.Status != ReleaseStatusReleased || !rec.Pushed || rec.Error != "" {
+		t.Errorf("record = %+v", rec)
+	}
+	if got := strings.Join(rec.Steps, ","); got != "bump,commit,check,tag,push,checksum" {
+		t.Errorf("Steps = %s", got)
+	}
+	if rec.OldVersion != "1.0.0" || rec.NewVersion != "1.0.1" || rec.Tag != "v1.0.1" {
+		t.Errorf("versions = %s -> %s, tag %s", rec.OldVersion, rec.NewVersion, rec.Tag)
+	}
+	if got := strings.Join(rec.Files, ","); got != "VERSION" {
+		t.Errorf("Files = %s", got)
+	}
+	if !rec.Finished.After(rec.Started) {
+		t.Errorf("Finished %v is not after Started %v", rec.Finished, rec.Started)
+	}
+	if got := runGit(t, repo, "log", "-1", "--format=%s"); got != "record release v1.0.1" {
+		t.Errorf("HEAD subject = %q", got)
+	}
+	if status, _ := git.Status(); len(status) != 0 {
+		t.Errorf("working tree not clean: %v", status)
+	}
+
+	// checksum 步骤写入的校验文件可以通过验证
+	sums := readTestFile(t, filepath.Join(dist, ChecksumFile))
+	if !strings.Contains(sums, "app.zip") {
+		t.Errorf("%s:\n%s", ChecksumFile, sums)
+	}
+	results, err := VerifyChecksums(dist, ChecksumFile, ManifestFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if !r.OK() {
+			t.Errorf("verify %+v", r)
+		}
+	}
+}
+
+func TestPipelineChecksumRequiresDir(t *testing.T) {
+	repo, _, git, config := newTestPipelineRepo(t, &PipelineStep{Uses: StepChecksum})
+	_, err := runTestPipeline(t, repo, git, config, nil)
+	if err == nil || !strings.Contains(err.Error(), "with dir is required") {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package obr
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"win_helper/pkg/util/versionUtils"
+)
+
+// DefaultReleaseBranches 默认允许发布的分支
+var DefaultReleaseBranches = []string{"main", "master"}
+
+// Preflight 发布前检查
+type Preflight struct {
+	Git Git
+	// Files 本次发布会修改的文件，工作区中只允许这些文件有改动
+	Files []string
+	// Branches 允许发布的分支，支持通配符 (如 release/*)，为空时不检查
+	Branches []string
+	// Tag 要创建的标签，为空时不检查标签
+	Tag string
+	// Remote 是否检查远程标签
+	Remote bool
+	// Version 新版本号，必须大于最近的标签
+	Version string
+	// TagTemplate 标签名模板，只有符合模板的标签参与版本比较，为空时比较 1.0.0 和 v1.0.0 形式的标签
+	TagTemplate string
+}
+
+// Check 执行全部检查，返回所有未通过的检查
+func (p *Preflight) Check() []error {
+	var errs []error
+	if err := p.CheckClean(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := p.CheckBranch(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := p.CheckTag(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := p.CheckVersion(); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// CheckClean 工作区中只有 Files 有改动
+func (p *Preflight) CheckClean() error {
+	changed, err := p.Git.Status()
+	if err != nil {
+		return err
+	}
+	var unexpected []string
+	for _, f := range changed {
+		if !containsPath(p.Files, f) {
+			unexpected = append(unexpected, f)
+		}
+	}
+	if len(unexpected) > 0 {
+		return fmt.Errorf("working tree has uncommitted changes: %s", strings.Join(unexpected, ", "))
+	}
+	return nil
+}
+
+// CheckBranch 当前分支在 Branches 中
+func (p *Preflight) CheckBranch() error {
+	if len(p.Branches) == 0 {
+		return nil
+	}
+	branch, err := p.Git.Branch()
+	if err != nil {
+		return err
+	}
+	for _, pattern := range p.Branches {
+		if ok, _ := path.Match(pattern, branch); ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("branch %s is not allowed for release (allowed: %s)", branch, strings.Join(p.Branches, ", "))
+}
+
+// CheckTag 标签在本地和远程都不存在
+func (p *Preflight) CheckTag() error {
+	if p.Tag == "" {
+		return nil
+	}
+	exists, err := p.Git.TagExists(p.Tag)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("tag %s already exists", p.Tag)
+	}
+	if !p.Remote {
+		return nil
+	}
+	if exists, err = p.Git.RemoteTagExists(p.Tag); err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("tag %s already exists on origin", p.Tag)
+	}
+	return nil
+}
+
+// CheckVersion 新版本号大于最近的版本标签，只比较符合 TagTemplate 的标签
+func (p *Preflight) CheckVersion() error {
+	if p.Version == "" {
+		return nil
+	}
+	latest, err := p.Git.LastTag("HEAD", TagPatterns(p.TagTemplate)...)
+	if err != nil || latest == "" {
+		return err
+	}
+	if !versionUtils.LessThan(VersionFromTag(p.TagTemplate, latest), trimVersionPrefix(p.Version)) {
+		return fmt.Errorf("version %s is not greater than latest tag %s", p.Version, latest)
+	}
+	return nil
+}
+
+// trimVersionPrefix 去掉标签的 v 前缀
+func trimVersionPrefix(version string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(version, "v"), "V")
+}
+
+// containsPath 比较绝对路径，Windows 下不区分大小写
+func containsPath(files []string, file string) bool {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		abs = file
+	}
+	for _, f := range files {
+		a, err := filepath.Abs(f)
+		if err != nil {
+			a = f
+		}
+		if a == abs || (runtime.GOOS == "windows" && strings.EqualFold(a, abs)) {
+			return true
+		}
+	}
+	return false
+}
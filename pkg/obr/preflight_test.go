@@ -0,0 +1,48 @@
+package obr
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPreflightCheckVersionIgnoresOtherTags(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	git, _ := newTestExecGit(repo)
+	writeTestFile(t, filepath.Join(repo, "VERSION"), "1.2.0")
+	runGit(t, repo, "add", ".")
+	runGit(t, repo, "commit", "--quiet", "-m", "release 1.2.0")
+	runGit(t, repo, "tag", "v1.2.0")
+	writeTestFile(t, filepath.Join(repo, "deploy.txt"), "prod")
+	runGit(t, repo, "add", ".")
+	runGit(t, repo, "commit", "--quiet", "-m", "deploy")
+	runGit(t, repo, "tag", "deploy-prod")
+
+	for _, template := range []string{"v{{ version }}", ""} {
+		check := &Preflight{Git: git, Version: "1.3.0", TagTemplate: template}
+		if err := check.CheckVersion(); err != nil {
+			t.Errorf("template %q: CheckVersion(1.3.0) = %v", template, err)
+		}
+		check.Version = "1.1.0"
+		if err := check.CheckVersion(); err == nil || !strings.Contains(err.Error(), "v1.2.0") {
+			t.Errorf("template %q: CheckVersion(1.1.0) = %v, want an error about v1.2.0", template, err)
+		}
+	}
+
+	// 模板不同的标签不参与比较
+	check := &Preflight{Git: git, Version: "0.1.0", TagTemplate: "release-{{ version }}"}
+	if err := check.CheckVersion(); err != nil {
+		t.Errorf("CheckVersion() with no matching tags = %v", err)
+	}
+}
+
+func TestPreflightCheckTag(t *testing.T) {
+	git := NewFakeGit()
+	git.Tags = []*FakeTag{{Name: "v1.0.0"}}
+	if err := (&Preflight{Git: git, Tag: "v1.0.0"}).CheckTag(); err == nil {
+		t.Error("CheckTag() should fail for an existing local tag")
+	}
+	if err := (&Preflight{Git: git, Tag: "v1.1.0"}).CheckTag(); err != nil {
+		t.Errorf("CheckTag() = %v", err)
+	}
+}
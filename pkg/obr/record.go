@@ -0,0 +1,135 @@
+package obr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+
+	"github.com/gookit/goutil/fsutil"
+
+	"win_helper/pkg/util/fileutil"
+)
+
+// ReleaseFile 默认的发布记录文件
+const ReleaseFile = "release.json"
+
+// 发布记录的状态
+const (
+	ReleaseStatusBumped     = "bumped"
+	ReleaseStatusReleased   = "released"
+	ReleaseStatusFailed     = "failed"
+	ReleaseStatusRolledBack = "rolled-back"
+)
+
+// ReleaseRecord 一次发布的记录
+type ReleaseRecord struct {
+	Command    string        `json:"command"`
+	OldVersion string        `json:"old_version"`
+	NewVersion string        `json:"new_version"`
+	Tag        string        `json:"tag,omitempty"`
+	Commit     string        `json:"commit,omitempty"`
+	Pushed     bool          `json:"pushed"`
+	Status     string        `json:"status"`
+	Error      string        `json:"error,omitempty"`
+	Files      []string      `json:"files"`
+	Steps      []string      `json:"steps"`
+	Hooks      []*HookResult `json:"hooks,omitempty"`
+	User       string        `json:"user"`
+	Started    time.Time     `json:"started"`
+	Finished   time.Time     `json:"finished"`
+}
+
+// NewReleaseRecord 根据发布流程生成记录，err 为 Release.Run 的结果。
+// 文件路径转换为相对于 dir 的路径。
+func NewReleaseRecord(r *Release, dir string, err error) *ReleaseRecord {
+	rec := &ReleaseRecord{
+		Commit:   r.commit,
+		Pushed:   r.pushed,
+		Hooks:    r.HookResults(),
+		User:     currentUser(),
+		Finished: time.Now(),
+		Files:    []string{},
+		Steps:    []string{},
+	}
+	for _, f := range r.Files() {
+		if rel, relErr := filepath.Rel(dir, f); relErr == nil {
+			f = filepath.ToSlash(rel)
+		}
+		rec.Files = append(rec.Files, f)
+	}
+	for _, step := range r.Steps() {
+		rec.Steps = append(rec.Steps, step.Name)
+	}
+	switch {
+	case err != nil && r.rolledBack:
+		rec.Status = ReleaseStatusRolledBack
+	case err != nil:
+		rec.Status = ReleaseStatusFailed
+	case r.pushed:
+		rec.Status = ReleaseStatusReleased
+	default:
+		rec.Status = ReleaseStatusBumped
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	return rec
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	for _, name := range []string{"USERNAME", "USER"} {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// LoadReleaseRecords 读取发布记录，文件不存在时返回空列表
+func LoadReleaseRecords(filename string) ([]*ReleaseRecord, error) {
+	if !fsutil.FileExist(filename) {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+	var records []*ReleaseRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("解析文件 %s 失败: %v", filename, err)
+	}
+	return records, nil
+}
+
+// AppendReleaseRecord 将记录追加到发布记录文件
+func AppendReleaseRecord(filename string, rec *ReleaseRecord) error {
+	records, err := LoadReleaseRecords(filename)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(append(records, rec)); err != nil {
+		return err
+	}
+	return fileutil.WriteFileAtomic(filename, buf.Bytes(), 0o644)
+}
+
+// CommitReleaseRecord 将推送后的发布记录追加到发布记录文件并单独提交。
+// 记录中包含发布提交的哈希，无法随发布提交，记录的提交在标签之后，随分支下次推送
+func CommitReleaseRecord(git Git, filename string, rec *ReleaseRecord) error {
+	if err := AppendReleaseRecord(filename, rec); err != nil {
+		return err
+	}
+	_, err := CommitChanged(git, fmt.Sprintf("record release %s", rec.Tag), filename)
+	return err
+}
@@ -0,0 +1,205 @@
+package obr
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gookit/goutil/fsutil"
+
+	"win_helper/pkg/util/fileutil"
+)
+
+// ReleaseStep 发布流程中已完成的步骤
+type ReleaseStep struct {
+	Name string
+	// undo 撤销该步骤，为空表示无法撤销 (如 push)
+	undo func() error
+}
+
+// Release 可回滚的发布流程。
+// 每个步骤完成后记录撤销操作，Run 中任一步骤失败时按相反顺序撤销已完成的步骤:
+// 还原文件、git reset 到发布前的提交、删除本地标签。
+type Release struct {
+	Git    Git
+	DryRun bool
+	// Out 输出步骤和回滚信息，同时记录在发布日志中
+	Out io.Writer
+	// Hooks 发布钩子，Dir 和 Env 为钩子执行的目录和追加的环境变量
+	Hooks *Hooks
+	Dir   string
+	Env   []string
+	steps []*ReleaseStep
+	files []string
+	hooks []*HookResult
+	log   strings.Builder
+
+	commit     string
+	message    string
+	pushed     bool
+	rolledBack bool
+}
+
+// NewRelease 创建发布流程，dryRun 时只输出要写入的文件，git 命令由 git 自身处理
+func NewRelease(git Git, dryRun bool) *Release {
+	return &Release{Git: git, DryRun: dryRun, Out: os.Stdout}
+}
+
+// Files 返回本次发布写入的文件
+func (r *Release) Files() []string {
+	return r.files
+}
+
+// HookResults 返回已执行的钩子命令
+func (r *Release) HookResults() []*HookResult {
+	return r.hooks
+}
+
+// Log 返回发布日志，包含步骤、钩子输出和回滚信息
+func (r *Release) Log() string {
+	return r.log.String()
+}
+
+// Steps 返回已完成的步骤
+func (r *Release) Steps() []*ReleaseStep {
+	return r.steps
+}
+
+func (r *Release) printf(format string, a ...any) {
+	_, _ = fmt.Fprintf(&r.log, format, a...)
+	if r.Out != nil {
+		_, _ = fmt.Fprintf(r.Out, format, a...)
+	}
+}
+
+func (r *Release) done(name string, undo func() error) {
+	r.steps = append(r.steps, &ReleaseStep{Name: name, undo: undo})
+	r.printf("step: %s\n", name)
+}
+
+// WriteFile 调用 write 修改文件，回滚时还原为修改前的内容，原来不存在的文件会被删除
+func (r *Release) WriteFile(filename string, write func() error) error {
+	if r.DryRun {
+		r.files = append(r.files, filename)
+		r.printf("[dry-run] write %s\n", filename)
+		return nil
+	}
+	existed := fsutil.FileExist(filename)
+	var backup []byte
+	var perm os.FileMode = 0o644
+	if existed {
+		info, err := os.Stat(filename)
+		if err != nil {
+			return err
+		}
+		perm = info.Mode().Perm()
+		if backup, err = os.ReadFile(filename); err != nil {
+			return fmt.Errorf("读取文件失败: %v", err)
+		}
+	}
+	restore := func() error {
+		if !existed {
+			return os.Remove(filename)
+		}
+		return fileutil.WriteFileAtomic(filename, backup, perm)
+	}
+	if err := write(); err != nil {
+		// 写入失败时文件可能已经改变
+		if fsutil.FileExist(filename) {
+			_ = restore()
+		}
+		return err
+	}
+	r.files = append(r.files, filename)
+	r.done("write "+filename, restore)
+	return nil
+}
+
+// Commit 提交本次发布写入的文件，回滚时 reset 到提交前的位置
+func (r *Release) Commit(message string) error {
+	files := r.Files()
+	if len(files) == 0 {
+		return fmt.Errorf("nothing to commit")
+	}
+	head, err := r.Git.Head()
+	if err != nil {
+		return err
+	}
+	if err := CommitChange(r.Git, message, files...); err != nil {
+		return err
+	}
+	var undo func() error
+	if head != "" {
+		undo = func() error { return r.Git.Reset(head) }
+	}
+	if r.commit, err = r.Git.Head(); err != nil {
+		return err
+	}
+	if r.message == "" {
+		r.message = strings.SplitN(message, "\n", 2)[0]
+	}
+	r.done("commit "+strings.SplitN(message, "\n", 2)[0], undo)
+	return nil
+}
+
+// Tag 创建标签，回滚时删除本地标签
+func (r *Release) Tag(name, message string) error {
+	if err := r.Git.Tag(name, message); err != nil {
+		return err
+	}
+	r.done("tag "+name, func() error { return r.Git.DeleteTag(name) })
+	return nil
+}
+
+// PushTags 推送标签，推送后无法撤销，应作为最后一步
+func (r *Release) PushTags() error {
+	if err := r.Git.PushTags(); err != nil {
+		return err
+	}
+	r.pushed = true
+	r.done("push tags", nil)
+	return nil
+}
+
+// Rollback 按相反顺序撤销已完成的步骤，返回撤销失败的错误
+func (r *Release) Rollback() error {
+	if r.rolledBack {
+		return nil
+	}
+	var failed []string
+	for i := len(r.steps) - 1; i >= 0; i-- {
+		step := r.steps[i]
+		if step.undo == nil {
+			r.printf("rollback: %s (cannot be undone)\n", step.Name)
+			continue
+		}
+		if err := step.undo(); err != nil {
+			r.printf("rollback: %s failed: %v\n", step.Name, err)
+			failed = append(failed, fmt.Sprintf("%s: %v", step.Name, err))
+			continue
+		}
+		r.printf("rollback: %s\n", step.Name)
+	}
+	r.rolledBack = true
+	if len(failed) > 0 {
+		return fmt.Errorf("rollback failed: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// Run 执行发布流程，失败时回滚已完成的步骤
+func (r *Release) Run(fn func(r *Release) error) error {
+	err := fn(r)
+	if err == nil {
+		return nil
+	}
+	if len(r.steps) == 0 {
+		return err
+	}
+	r.printf("release failed: %v\n", err)
+	if rollbackErr := r.Rollback(); rollbackErr != nil {
+		return fmt.Errorf("%v; %v", err, rollbackErr)
+	}
+	return fmt.Errorf("%v (rolled back)", err)
+}
@@ -0,0 +1,275 @@
+package obr
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// releaseVersion 模拟一次发布: 写入版本号、提交、打标签并推送
+func releaseVersion(filename string, version string) func(r *Release) error {
+	return func(r *Release) error {
+		err := r.WriteFile(filename, func() error {
+			return os.WriteFile(filename, []byte(version+"\n"), 0o644)
+		})
+		if err != nil {
+			return err
+		}
+		if err := r.Commit("update version " + version); err != nil {
+			return err
+		}
+		if err := r.Tag("v"+version, "release "+version); err != nil {
+			return err
+		}
+		return r.PushTags()
+	}
+}
+
+func readTestFile(t *testing.T, filename string) string {
+	t.Helper()
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestReleaseRollback(t *testing.T) {
+	for _, step := range []string{"add", "commit", "tag", "push"} {
+		t.Run(step, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "VERSION")
+			writeTestFile(t, filename, "1.0.0\n")
+			git := NewFakeGit()
+			initial := git.AddCommit("init").Hash
+			git.Errors[step] = errors.New("injected")
+
+			r := NewRelease(git, false)
+			r.Out = io.Discard
+			err := r.Run(releaseVersion(filename, "1.1.0"))
+			if err == nil || !strings.Contains(err.Error(), "injected") || !strings.Contains(err.Error(), "rolled back") {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if got := readTestFile(t, filename); got != "1.0.0\n" {
+				t.Fatalf("file not restored: %q", got)
+			}
+			if head, _ := git.Head(); head != initial {
+				t.Fatalf("HEAD = %s, want %s", head, initial)
+			}
+			if ok, _ := git.TagExists("v1.1.0"); ok {
+				t.Fatal("tag v1.1.0 not deleted")
+			}
+			if !strings.Contains(r.Log(), "rollback: write "+filename) {
+				t.Fatalf("rollback not logged:\n%s", r.Log())
+			}
+			// 重复回滚不再执行撤销
+			calls := len(git.Calls)
+			if err := r.Rollback(); err != nil || len(git.Calls) != calls {
+				t.Fatalf("second Rollback() = %v, calls %d -> %d", err, calls, len(git.Calls))
+			}
+		})
+	}
+}
+
+func TestReleaseRollbackNewFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "VERSION")
+	git := NewFakeGit()
+	git.AddCommit("init")
+	git.Errors["commit"] = errors.New("injected")
+
+	r := NewRelease(git, false)
+	r.Out = io.Discard
+	if err := r.Run(releaseVersion(filename, "1.0.0")); err == nil {
+		t.Fatal("Run() succeeded")
+	}
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Fatalf("file created by the release not removed: %v", err)
+	}
+}
+
+func TestReleaseRollbackFirstCommit(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "VERSION")
+	writeTestFile(t, filename, "0.0.0\n")
+	// 仓库中没有提交，提交无法通过 reset 撤销
+	git := NewFakeGit()
+	git.Errors["tag"] = errors.New("injected")
+
+	r := NewRelease(git, false)
+	r.Out = io.Discard
+	if err := r.Run(releaseVersion(filename, "1.0.0")); err == nil {
+		t.Fatal("Run() succeeded")
+	}
+	var commit *ReleaseStep
+	for _, s := range r.Steps() {
+		if strings.HasPrefix(s.Name, "commit ") {
+			commit = s
+		}
+	}
+	if commit == nil || commit.undo != nil {
+		t.Fatalf("first commit step = %+v, want a step without undo", commit)
+	}
+	if !strings.Contains(r.Log(), "rollback: commit update version 1.0.0 (cannot be undone)") {
+		t.Fatalf("log:\n%s", r.Log())
+	}
+	if len(git.Commits) != 1 {
+		t.Fatalf("commits = %d, want the first commit kept", len(git.Commits))
+	}
+	for _, call := range git.Calls {
+		if strings.HasPrefix(call, "git reset") {
+			t.Fatalf("unexpected %s", call)
+		}
+	}
+	if got := readTestFile(t, filename); got != "0.0.0\n" {
+		t.Fatalf("file not restored: %q", got)
+	}
+}
+
+func TestReleaseRollbackExecGit(t *testing.T) {
+	repo, remote := newTestRepo(t)
+	git, _ := newTestExecGit(repo)
+	filename := filepath.Join(repo, "VERSION")
+	writeTestFile(t, filename, "1.0.0\n")
+	if err := CommitChange(git, "init", filename); err != nil {
+		t.Fatal(err)
+	}
+	initial, _ := git.Head()
+	// 推送到不存在的远程仓库失败
+	if err := os.RemoveAll(remote); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRelease(git, false)
+	r.Out = io.Discard
+	if err := r.Run(releaseVersion(filename, "1.1.0")); err == nil {
+		t.Fatal("Run() succeeded")
+	}
+	if head, _ := git.Head(); head != initial {
+		t.Fatalf("HEAD = %s, want %s", head, initial)
+	}
+	if ok, _ := git.TagExists("v1.1.0"); ok {
+		t.Fatal("tag v1.1.0 not deleted")
+	}
+	if got := readTestFile(t, filename); got != "1.0.0\n" {
+		t.Fatalf("file not restored: %q", got)
+	}
+	if status, _ := git.Status(); len(status) != 0 {
+		t.Fatalf("working tree not clean: %v", status)
+	}
+}
+
+func TestReleaseDryRun(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "VERSION")
+	writeTestFile(t, filename, "1.0.0\n")
+	r := NewRelease(NewFakeGit(), true)
+	r.Out = io.Discard
+	err := r.WriteFile(filename, func() error {
+		return os.WriteFile(filename, []byte("2.0.0\n"), 0o644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestFile(t, filename); got != "1.0.0\n" {
+		t.Fatalf("dry-run wrote the file: %q", got)
+	}
+	if len(r.Files()) != 1 || len(r.Steps()) != 0 {
+		t.Fatalf("Files() = %v, Steps() = %d", r.Files(), len(r.Steps()))
+	}
+}
+
+func TestReleaseHookFiles(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	git, _ := newTestExecGit(repo)
+	filename := filepath.Join(repo, "VERSION")
+	writeTestFile(t, filename, "1.0.0\n")
+	if err := CommitChange(git, "init", filename); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRelease(git, false)
+	r.Out = io.Discard
+	r.Dir = repo
+	r.Hooks = &Hooks{PostBump: []string{"echo asset > asset.txt"}, PreTag: []string{"echo tag > tag.txt"}}
+	err := r.Run(func(r *Release) error {
+		err := r.WriteFile(filename, func() error {
+			return os.WriteFile(filename, []byte("1.1.0\n"), 0o644)
+		})
+		if err != nil {
+			return err
+		}
+		if err := r.RunHook(HookPostBump); err != nil {
+			return err
+		}
+		if err := r.Commit("update version 1.1.0"); err != nil {
+			return err
+		}
+		if err := r.RunHook(HookPreTag); err != nil {
+			return err
+		}
+		return r.Tag("v1.1.0", "release 1.1.0")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := git.Status(); len(status) != 0 {
+		t.Fatalf("hook files not committed: %v", status)
+	}
+	// 标签指向的提交包含两个钩子生成的文件
+	files := runGit(t, repo, "ls-tree", "--name-only", "v1.1.0")
+	for _, name := range []string{"VERSION", "asset.txt", "tag.txt"} {
+		if !strings.Contains(files, name) {
+			t.Fatalf("%s not in tagged commit:\n%s", name, files)
+		}
+	}
+}
+
+func TestCommitReleaseRecordPushed(t *testing.T) {
+	repo, remote := newTestRepo(t)
+	git, _ := newTestExecGit(repo)
+	filename := filepath.Join(repo, "VERSION")
+	writeTestFile(t, filename, "1.0.0\n")
+	if err := CommitChange(git, "init", filename); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRelease(git, false)
+	r.Out = io.Discard
+	if err := r.Run(releaseVersion(filename, "1.1.0")); err != nil {
+		t.Fatal(err)
+	}
+	releaseFile := filepath.Join(repo, ReleaseFile)
+	rec := NewReleaseRecord(r, repo, nil)
+	rec.Tag = "v1.1.0"
+	if err := CommitReleaseRecord(git, releaseFile, rec); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := LoadReleaseRecords(releaseFile)
+	if err != nil || len(records) != 1 {
+		t.Fatalf("LoadReleaseRecords() = %v, %v", records, err)
+	}
+	got := records[0]
+	if want := runGit(t, remote, "rev-parse", "v1.1.0^{}"); got.Commit != want {
+		t.Errorf("Commit = %q, want the pushed tag commit %q", got.Commit, want)
+	}
+	if !got.Pushed || got.Status != ReleaseStatusReleased {
+		t.Errorf("Pushed = %v, Status = %q", got.Pushed, got.Status)
+	}
+	if want := []string{"write " + filename, "commit update version 1.1.0", "tag v1.1.0", "push tags"}; strings.Join(got.Steps, "|") != strings.Join(want, "|") {
+		t.Errorf("Steps = %q, want %q", got.Steps, want)
+	}
+	if len(got.Files) != 1 || got.Files[0] != "VERSION" {
+		t.Errorf("Files = %q", got.Files)
+	}
+	// 记录单独提交在标签之后
+	if subject := runGit(t, repo, "log", "-1", "--format=%s"); subject != "record release v1.1.0" {
+		t.Errorf("HEAD subject = %q", subject)
+	}
+	if parent := runGit(t, repo, "rev-parse", "HEAD^"); parent != got.Commit {
+		t.Errorf("record commit parent = %s, want %s", parent, got.Commit)
+	}
+	if status, _ := git.Status(); len(status) != 0 {
+		t.Errorf("working tree not clean: %v", status)
+	}
+}
@@ -0,0 +1,349 @@
+package obr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"win_helper/pkg/util/fileutil"
+)
+
+// 版本号文件的格式
+const (
+	SyncFormatFile   = "file"
+	SyncFormatRegex  = "regex"
+	SyncFormatPython = "python"
+	SyncFormatJSON   = "json"
+	SyncFormatTOML   = "toml"
+	SyncFormatISS    = "iss"
+)
+
+// pythonVersionPattern 匹配 __version__ = "x.y.z"
+const pythonVersionPattern = `__version__\s*=\s*["']([^"']*)["']`
+
+// SyncTarget 记录版本号的文件
+type SyncTarget struct {
+	Path string `json:"path" yaml:"path"`
+	// Format 为空时根据文件名推断
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+	// Key json/toml 使用点号分隔的键 (如 tool.poetry.version)，iss 使用 define:Name 或 setup:Key
+	Key string `json:"key,omitempty" yaml:"key,omitempty"`
+	// Pattern regex 格式的正则表达式，第一个分组为版本号
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+}
+
+// SyncConfig 版本同步配置，Source 为版本号的来源
+type SyncConfig struct {
+	Source  *SyncTarget   `json:"source" yaml:"source"`
+	Targets []*SyncTarget `json:"targets" yaml:"targets"`
+}
+
+// SyncResult 单个文件的同步结果
+type SyncResult struct {
+	Target     *SyncTarget
+	OldVersion string
+	NewVersion string
+	Err        error
+}
+
+// Changed 版本号是否被修改
+func (r *SyncResult) Changed() bool {
+	return r.Err == nil && r.OldVersion != r.NewVersion
+}
+
+// format 返回目标的格式，未设置时根据文件名推断
+func (t *SyncTarget) format() string {
+	if t.Format != "" {
+		return strings.ToLower(t.Format)
+	}
+	switch strings.ToLower(filepath.Ext(t.Path)) {
+	case ".json":
+		return SyncFormatJSON
+	case ".toml":
+		return SyncFormatTOML
+	case ".iss":
+		return SyncFormatISS
+	case ".py":
+		return SyncFormatPython
+	}
+	if t.Pattern != "" {
+		return SyncFormatRegex
+	}
+	return SyncFormatFile
+}
+
+func (t *SyncTarget) String() string {
+	switch f := t.format(); f {
+	case SyncFormatJSON, SyncFormatTOML, SyncFormatISS:
+		return fmt.Sprintf("%s (%s %s)", t.Path, f, t.key())
+	default:
+		return fmt.Sprintf("%s (%s)", t.Path, f)
+	}
+}
+
+func (t *SyncTarget) key() string {
+	if t.Key != "" {
+		return t.Key
+	}
+	if t.format() == SyncFormatISS {
+		return "define:" + ISSVersionDefine
+	}
+	return "version"
+}
+
+func (t *SyncTarget) pattern() (*regexp.Regexp, error) {
+	pattern := t.Pattern
+	if pattern == "" && t.format() == SyncFormatPython {
+		pattern = pythonVersionPattern
+	}
+	if pattern == "" {
+		return nil, fmt.Errorf("%s: missing pattern", t.Path)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid pattern: %v", t.Path, err)
+	}
+	if re.NumSubexp() < 1 {
+		return nil, fmt.Errorf("%s: pattern must have a capture group", t.Path)
+	}
+	return re, nil
+}
+
+// locate 返回版本号在文本中的位置
+func (t *SyncTarget) locate(text []byte) (int, int, error) {
+	switch t.format() {
+	case SyncFormatFile:
+		trimmed := bytes.TrimSpace(text)
+		start := bytes.Index(text, trimmed)
+		return start, start + len(trimmed), nil
+	case SyncFormatRegex, SyncFormatPython:
+		re, err := t.pattern()
+		if err != nil {
+			return 0, 0, err
+		}
+		m := re.FindSubmatchIndex(text)
+		if m == nil || m[2] < 0 {
+			return 0, 0, fmt.Errorf("%s: pattern not matched", t.Path)
+		}
+		return m[2], m[3], nil
+	case SyncFormatJSON:
+		return jsonStringRange(text, strings.Split(t.key(), "."))
+	case SyncFormatTOML:
+		return tomlStringRange(text, t.key())
+	}
+	return 0, 0, fmt.Errorf("%s: unknown format %s", t.Path, t.Format)
+}
+
+// Read 读取版本号，相对路径基于 dir
+func (t *SyncTarget) Read(dir string) (string, error) {
+	filename := resolveSyncPath(dir, t.Path)
+	if t.format() == SyncFormatISS {
+		v, err := GetISSValue(filename, t.key())
+		if err != nil {
+			return "", err
+		}
+		return v.Value, nil
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	text, _, err := fileutil.DecodeText(data)
+	if err != nil {
+		return "", err
+	}
+	start, end, err := t.locate(text)
+	if err != nil {
+		return "", err
+	}
+	value := string(text[start:end])
+	if t.format() == SyncFormatJSON {
+		_ = json.Unmarshal(text[start:end], &value)
+	}
+	return value, nil
+}
+
+// Write 写入版本号，只替换版本号所在的文本，保留文件的编码和其余内容
+func (t *SyncTarget) Write(dir string, version string) error {
+	filename := resolveSyncPath(dir, t.Path)
+	if t.format() == SyncFormatISS {
+		_, err := SaveISSValue(filename, t.key(), version, false)
+		return err
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	text, enc, err := fileutil.DecodeText(data)
+	if err != nil {
+		return err
+	}
+	start, end, err := t.locate(text)
+	if err != nil {
+		return err
+	}
+	value := []byte(version)
+	if t.format() == SyncFormatJSON {
+		if value, err = json.Marshal(version); err != nil {
+			return err
+		}
+	}
+	out := append(append(append([]byte{}, text[:start]...), value...), text[end:]...)
+	if out, err = fileutil.EncodeText(out, enc, fileutil.HasBOM(data)); err != nil {
+		return err
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteFileAtomic(filename, out, info.Mode().Perm())
+}
+
+func resolveSyncPath(dir string, path string) string {
+	if filepath.IsAbs(path) || dir == "" {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// Sync 将 version 写入所有目标文件，dryRun 时只读取旧值。
+// 单个文件失败不会中断其他文件，错误记录在结果中。
+func (c *SyncConfig) Sync(dir string, version string, dryRun bool) []*SyncResult {
+	results := make([]*SyncResult, 0, len(c.Targets))
+	for _, target := range c.Targets {
+		r := &SyncResult{Target: target, NewVersion: version}
+		r.OldVersion, r.Err = target.Read(dir)
+		if r.Err == nil && r.Changed() && !dryRun {
+			r.Err = target.Write(dir, version)
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// Validate 检查配置
+func (c *SyncConfig) Validate() error {
+	if c.Source == nil || c.Source.Path == "" {
+		return fmt.Errorf("sync-version: missing source")
+	}
+	if len(c.Targets) == 0 {
+		return fmt.Errorf("sync-version: missing targets")
+	}
+	for _, t := range append([]*SyncTarget{c.Source}, c.Targets...) {
+		if t.Path == "" {
+			return fmt.Errorf("sync-version: target missing path")
+		}
+		switch t.format() {
+		case SyncFormatFile, SyncFormatJSON, SyncFormatTOML, SyncFormatISS:
+		case SyncFormatRegex, SyncFormatPython:
+			if _, err := t.pattern(); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%s: unknown format %s", t.Path, t.Format)
+		}
+	}
+	return nil
+}
+
+// jsonStringRange 按键路径查找字符串值，返回值 (包含引号) 在内容中的位置
+func jsonStringRange(data []byte, path []string) (int, int, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	start, end, err := jsonFind(dec, data, path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("json key %s: %v", strings.Join(path, "."), err)
+	}
+	return start, end, nil
+}
+
+func jsonFind(dec *json.Decoder, data []byte, path []string) (int, int, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return 0, 0, err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return 0, 0, fmt.Errorf("not an object")
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return 0, 0, err
+		}
+		if key, _ := tok.(string); key != path[0] {
+			if err := jsonSkip(dec); err != nil {
+				return 0, 0, err
+			}
+			continue
+		}
+		if len(path) > 1 {
+			return jsonFind(dec, data, path[1:])
+		}
+		offset := int(dec.InputOffset())
+		tok, err = dec.Token()
+		if err != nil {
+			return 0, 0, err
+		}
+		if _, ok := tok.(string); !ok {
+			return 0, 0, fmt.Errorf("not a string")
+		}
+		end := int(dec.InputOffset())
+		return offset + bytes.IndexByte(data[offset:end], '"'), end, nil
+	}
+	return 0, 0, fmt.Errorf("not found")
+}
+
+// jsonSkip 跳过一个值
+func jsonSkip(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+var (
+	tomlTableRe = regexp.MustCompile(`^\s*\[\s*([^\[\]]+?)\s*\]\s*(#.*)?$`)
+	tomlArrayRe = regexp.MustCompile(`^\s*\[\[`)
+)
+
+// tomlStringRange 按 "table.key" 查找字符串值，返回引号内内容的位置
+func tomlStringRange(data []byte, key string) (int, int, error) {
+	table, name := "", key
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		table, name = key[:i], key[i+1:]
+	}
+	valueRe := regexp.MustCompile(`^\s*"?` + regexp.QuoteMeta(name) + `"?\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	current, offset := "", 0
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		switch {
+		case tomlArrayRe.Match(line):
+			current = "\x00"
+		case tomlTableRe.Match(line):
+			current = string(tomlTableRe.FindSubmatch(line)[1])
+		case current == table:
+			if m := valueRe.FindSubmatchIndex(line); m != nil {
+				if m[2] >= 0 {
+					return offset + m[2], offset + m[3], nil
+				}
+				return offset + m[4], offset + m[5], nil
+			}
+		}
+		offset += len(line)
+	}
+	return 0, 0, fmt.Errorf("toml key %s not found", key)
+}
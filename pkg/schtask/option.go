@@ -0,0 +1,104 @@
+package schtask
+
+import "time"
+
+type Option func(t *Task) error
+
+func WithBasePath(basePath string) Option {
+	return func(t *Task) error {
+		t.BasePath = basePath
+		return nil
+	}
+}
+
+func WithName(name string) Option {
+	return func(t *Task) error {
+		t.Name = name
+		return nil
+	}
+}
+
+func WithDescription(description string) Option {
+	return func(t *Task) error {
+		t.Description = description
+		return nil
+	}
+}
+
+func WithAuthor(author string) Option {
+	return func(t *Task) error {
+		t.Author = author
+		return nil
+	}
+}
+
+func WithCommand(command string) Option {
+	return func(t *Task) error {
+		t.Command = command
+		return nil
+	}
+}
+
+func WithArguments(arguments string) Option {
+	return func(t *Task) error {
+		t.Arguments = arguments
+		return nil
+	}
+}
+
+func WithWorkingDirectory(workingDirectory string) Option {
+	return func(t *Task) error {
+		t.WorkingDirectory = workingDirectory
+		return nil
+	}
+}
+
+func WithTriggers(triggers []string) Option {
+	return func(t *Task) error {
+		t.Triggers = triggers
+		return nil
+	}
+}
+
+func WithStartDate(startDate time.Time) Option {
+	return func(t *Task) error {
+		t.StartDate = startDate
+		return nil
+	}
+}
+
+func WithRunAs(runAs string) Option {
+	return func(t *Task) error {
+		t.RunAs = runAs
+		return nil
+	}
+}
+
+func WithHighest(highest bool) Option {
+	return func(t *Task) error {
+		t.Highest = highest
+		return nil
+	}
+}
+
+func WithRetry(count int, interval time.Duration) Option {
+	return func(t *Task) error {
+		t.RetryCount = count
+		t.RetryInterval = interval
+		return nil
+	}
+}
+
+func WithTimeLimit(timeLimit time.Duration) Option {
+	return func(t *Task) error {
+		t.TimeLimit = timeLimit
+		return nil
+	}
+}
+
+func WithForce(force bool) Option {
+	return func(t *Task) error {
+		t.force = force
+		return nil
+	}
+}
@@ -0,0 +1,333 @@
+package schtask
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gookit/goutil/fsutil"
+	"golang.org/x/text/encoding/unicode"
+)
+
+const taskNamespace = "http://schemas.microsoft.com/windows/2004/02/mit/task"
+
+// TaskXML Task Scheduler 1.2 任务定义
+type TaskXML struct {
+	XMLName          xml.Name          `xml:"Task"`
+	Version          string            `xml:"version,attr"`
+	Xmlns            string            `xml:"xmlns,attr"`
+	RegistrationInfo *RegistrationInfo `xml:"RegistrationInfo"`
+	Triggers         *Triggers         `xml:"Triggers"`
+	Principals       *Principals       `xml:"Principals"`
+	Settings         *Settings         `xml:"Settings"`
+	Actions          *Actions          `xml:"Actions"`
+}
+
+type RegistrationInfo struct {
+	Author      string `xml:"Author,omitempty"`
+	Description string `xml:"Description,omitempty"`
+	URI         string `xml:"URI,omitempty"`
+}
+
+type Triggers struct {
+	CalendarTriggers []*CalendarTrigger `xml:"CalendarTrigger,omitempty"`
+	BootTriggers     []*BootTrigger     `xml:"BootTrigger,omitempty"`
+	LogonTriggers    []*LogonTrigger    `xml:"LogonTrigger,omitempty"`
+	IdleTriggers     []*IdleTrigger     `xml:"IdleTrigger,omitempty"`
+}
+
+// CalendarTrigger 按日或按周执行
+type CalendarTrigger struct {
+	StartBoundary  string          `xml:"StartBoundary"`
+	Enabled        bool            `xml:"Enabled"`
+	ScheduleByDay  *ScheduleByDay  `xml:"ScheduleByDay,omitempty"`
+	ScheduleByWeek *ScheduleByWeek `xml:"ScheduleByWeek,omitempty"`
+}
+
+type ScheduleByDay struct {
+	DaysInterval int `xml:"DaysInterval"`
+}
+
+type ScheduleByWeek struct {
+	DaysOfWeek    *DaysOfWeek `xml:"DaysOfWeek"`
+	WeeksInterval int         `xml:"WeeksInterval"`
+}
+
+// DaysOfWeek 每个非空字段输出为一个空元素，例如 <Monday></Monday>
+type DaysOfWeek struct {
+	Monday    *struct{} `xml:"Monday,omitempty"`
+	Tuesday   *struct{} `xml:"Tuesday,omitempty"`
+	Wednesday *struct{} `xml:"Wednesday,omitempty"`
+	Thursday  *struct{} `xml:"Thursday,omitempty"`
+	Friday    *struct{} `xml:"Friday,omitempty"`
+	Saturday  *struct{} `xml:"Saturday,omitempty"`
+	Sunday    *struct{} `xml:"Sunday,omitempty"`
+}
+
+// BootTrigger 系统启动时执行
+type BootTrigger struct {
+	Enabled bool   `xml:"Enabled"`
+	Delay   string `xml:"Delay,omitempty"`
+}
+
+// LogonTrigger 用户登录时执行，UserId 为空时任意用户登录都会触发
+type LogonTrigger struct {
+	Enabled bool   `xml:"Enabled"`
+	UserId  string `xml:"UserId,omitempty"`
+	Delay   string `xml:"Delay,omitempty"`
+}
+
+// IdleTrigger 系统空闲时执行
+type IdleTrigger struct {
+	Enabled bool `xml:"Enabled"`
+}
+
+type Principals struct {
+	Principal *Principal `xml:"Principal"`
+}
+
+type Principal struct {
+	Id        string `xml:"id,attr"`
+	UserId    string `xml:"UserId"`
+	LogonType string `xml:"LogonType,omitempty"`
+	RunLevel  string `xml:"RunLevel,omitempty"`
+}
+
+type Settings struct {
+	MultipleInstancesPolicy    string            `xml:"MultipleInstancesPolicy"`
+	DisallowStartIfOnBatteries bool              `xml:"DisallowStartIfOnBatteries"`
+	StopIfGoingOnBatteries     bool              `xml:"StopIfGoingOnBatteries"`
+	StartWhenAvailable         bool              `xml:"StartWhenAvailable"`
+	RunOnlyIfIdle              bool              `xml:"RunOnlyIfIdle"`
+	IdleSettings               *IdleSettings     `xml:"IdleSettings,omitempty"`
+	Enabled                    bool              `xml:"Enabled"`
+	ExecutionTimeLimit         string            `xml:"ExecutionTimeLimit,omitempty"`
+	RestartOnFailure           *RestartOnFailure `xml:"RestartOnFailure,omitempty"`
+}
+
+type IdleSettings struct {
+	Duration      string `xml:"Duration"`
+	WaitTimeout   string `xml:"WaitTimeout"`
+	StopOnIdleEnd bool   `xml:"StopOnIdleEnd"`
+	RestartOnIdle bool   `xml:"RestartOnIdle"`
+}
+
+// RestartOnFailure 任务失败后的重试设置
+type RestartOnFailure struct {
+	Interval string `xml:"Interval"`
+	Count    int    `xml:"Count"`
+}
+
+type Actions struct {
+	Context string `xml:"Context,attr"`
+	Exec    *Exec  `xml:"Exec"`
+}
+
+type Exec struct {
+	Command          string `xml:"Command"`
+	Arguments        string `xml:"Arguments,omitempty"`
+	WorkingDirectory string `xml:"WorkingDirectory,omitempty"`
+}
+
+// ToXML 输出带 UTF-16 声明的 XML 文本
+func (t *TaskXML) ToXML() (string, error) {
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-16"?>` + "\n")
+	encoder := xml.NewEncoder(&b)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(t); err != nil {
+		return "", fmt.Errorf("XML 编码失败: %v", err)
+	}
+	return b.String(), nil
+}
+
+// 内置账户对应的 SID
+var builtinAccounts = map[string]string{
+	"SYSTEM":          "S-1-5-18",
+	"LOCAL SERVICE":   "S-1-5-19",
+	"NETWORK SERVICE": "S-1-5-20",
+}
+
+// Task 计划任务定义
+type Task struct {
+	BasePath string
+	force    bool
+
+	Name             string
+	Description      string
+	Author           string
+	Command          string
+	Arguments        string
+	WorkingDirectory string
+	Triggers         []string
+	StartDate        time.Time
+	RunAs            string
+	Highest          bool
+	RetryCount       int
+	RetryInterval    time.Duration
+	TimeLimit        time.Duration
+}
+
+func NewDefaultTask() *Task {
+	return &Task{
+		RunAs:         "SYSTEM",
+		StartDate:     time.Now(),
+		RetryInterval: 5 * time.Minute,
+		TimeLimit:     72 * time.Hour,
+	}
+}
+
+func NewTask(opts ...Option) (*Task, error) {
+	t := NewDefaultTask()
+	for _, opt := range opts {
+		if err := opt(t); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// isBuiltinAccount 判断是否为无需密码的内置账户
+func (t *Task) isBuiltinAccount() bool {
+	_, ok := builtinAccounts[strings.ToUpper(t.RunAs)]
+	return ok
+}
+
+// BuildTaskXML 根据任务定义构造 Task Scheduler XML
+func (t *Task) BuildTaskXML() (*TaskXML, error) {
+	if t.Name == "" {
+		return nil, fmt.Errorf("missing name")
+	}
+	if t.Command == "" {
+		return nil, fmt.Errorf("missing command")
+	}
+	if len(t.Triggers) == 0 {
+		return nil, fmt.Errorf("missing trigger")
+	}
+
+	taskXML := &TaskXML{
+		Version: "1.2",
+		Xmlns:   taskNamespace,
+		RegistrationInfo: &RegistrationInfo{
+			Author:      t.Author,
+			Description: t.Description,
+			URI:         `\` + t.Name,
+		},
+		Triggers: &Triggers{},
+		Principals: &Principals{Principal: &Principal{
+			Id:       "Author",
+			UserId:   t.RunAs,
+			RunLevel: "LeastPrivilege",
+		}},
+		Settings: &Settings{
+			MultipleInstancesPolicy: "IgnoreNew",
+			StartWhenAvailable:      true,
+			Enabled:                 true,
+			ExecutionTimeLimit:      isoDuration(t.TimeLimit),
+		},
+		Actions: &Actions{
+			Context: "Author",
+			Exec: &Exec{
+				Command:          t.Command,
+				Arguments:        t.Arguments,
+				WorkingDirectory: t.WorkingDirectory,
+			},
+		},
+	}
+
+	principal := taskXML.Principals.Principal
+	if sid, ok := builtinAccounts[strings.ToUpper(t.RunAs)]; ok {
+		principal.UserId = sid
+		principal.LogonType = "ServiceAccount"
+	} else {
+		principal.LogonType = "Password"
+	}
+	if t.Highest {
+		principal.RunLevel = "HighestAvailable"
+	}
+
+	if t.RetryCount > 0 {
+		taskXML.Settings.RestartOnFailure = &RestartOnFailure{
+			Interval: isoDuration(t.RetryInterval),
+			Count:    t.RetryCount,
+		}
+	}
+
+	for _, trigger := range t.Triggers {
+		if err := addTrigger(taskXML, trigger, t.StartDate); err != nil {
+			return nil, err
+		}
+	}
+	return taskXML, nil
+}
+
+// InstallScript 返回注册任务的 schtasks 脚本
+func (t *Task) InstallScript() string {
+	args := []string{"schtasks", "/Create", "/TN", quote(t.Name), "/XML", quote("%~dp0" + t.xmlFileName()), "/F"}
+	if !t.isBuiltinAccount() {
+		args = append(args, "/RU", quote(t.RunAs), "/RP", "*")
+	}
+	lines := []string{
+		"@echo off",
+		strings.Join(args, " "),
+		"",
+	}
+	return strings.Join(lines, "\r\n")
+}
+
+func quote(s string) string {
+	return `"` + s + `"`
+}
+
+func (t *Task) xmlFileName() string {
+	return fmt.Sprintf("%s-task.xml", t.Name)
+}
+
+func (t *Task) scriptFileName() string {
+	return fmt.Sprintf("%s-task-install.bat", t.Name)
+}
+
+// GenerateTaskXML 生成 UTF-16LE 编码的任务 XML 文件
+func (t *Task) GenerateTaskXML() error {
+	taskXML, err := t.BuildTaskXML()
+	if err != nil {
+		return err
+	}
+	out, err := taskXML.ToXML()
+	if err != nil {
+		return err
+	}
+	out = strings.ReplaceAll(out, "\n", "\r\n")
+	data, err := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder().Bytes([]byte(out))
+	if err != nil {
+		return fmt.Errorf("UTF-16 编码失败: %v", err)
+	}
+	return t.writeFile(t.xmlFileName(), data)
+}
+
+// GenerateInstallScript 生成 schtasks /Create 脚本
+func (t *Task) GenerateInstallScript() error {
+	return t.writeFile(t.scriptFileName(), []byte(t.InstallScript()))
+}
+
+func (t *Task) writeFile(name string, data []byte) error {
+	filename := filepath.Join(t.BasePath, name)
+	if fsutil.FileExist(filename) && !t.force {
+		return fmt.Errorf("任务文件 %s 已存在", filename)
+	}
+	if err := os.WriteFile(filename, data, 0o644); err != nil {
+		return fmt.Errorf("写入任务文件失败。%v", err)
+	}
+	return nil
+}
+
+func (t *Task) Generate() error {
+	if err := t.GenerateTaskXML(); err != nil {
+		return err
+	}
+	return t.GenerateInstallScript()
+}
@@ -0,0 +1,122 @@
+package schtask
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/unicode"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+// checkGolden 比较 testdata 中的 golden 文件，golden 文件使用 LF 换行
+func checkGolden(t *testing.T, name string, got string) {
+	t.Helper()
+	golden := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(want) != got {
+		t.Fatalf("%s differs:\n%s\nrun go test ./pkg/schtask -run TestGenerateGolden -update", golden, got)
+	}
+}
+
+// readCRLF 读取生成的文件，检查全部为 CRLF 换行后转换为 LF
+func readCRLF(t *testing.T, data []byte) string {
+	t.Helper()
+	text := string(data)
+	if strings.Count(text, "\n") != strings.Count(text, "\r\n") {
+		t.Fatalf("not all lines end with CRLF:\n%q", text)
+	}
+	return strings.ReplaceAll(text, "\r\n", "\n")
+}
+
+func TestGenerateGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"daily", []Option{
+			WithName("backup"),
+			WithDescription("nightly backup"),
+			WithAuthor("ops"),
+			WithCommand(`C:\backup\backup.exe`),
+			WithArguments("--full"),
+			WithWorkingDirectory(`C:\backup`),
+			WithTriggers([]string{"daily@02:30"}),
+			WithRetry(3, 10*time.Minute),
+		}},
+		{"logon", []Option{
+			WithName("tray"),
+			WithCommand(`C:\Program Files\tray\tray.exe`),
+			WithTriggers([]string{`logon/PT30S@CORP\alice`}),
+			WithRunAs(`CORP\alice`),
+			WithHighest(true),
+			WithTimeLimit(0),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			opts := append([]Option{WithBasePath(dir), WithStartDate(testStartDate)}, tt.opts...)
+			task, err := NewTask(opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := task.Generate(); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, task.xmlFileName()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// schtasks 要求 XML 文件的编码与声明一致
+			if !strings.HasPrefix(string(data), "\xff\xfe") {
+				t.Fatal("task xml does not start with a UTF-16LE BOM")
+			}
+			decoded, err := unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM).NewDecoder().Bytes(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.name+"-task.xml", readCRLF(t, decoded))
+
+			script, err := os.ReadFile(filepath.Join(dir, task.scriptFileName()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.name+"-task-install.bat", readCRLF(t, script))
+		})
+	}
+}
+
+func TestBuildTaskXMLMissingFields(t *testing.T) {
+	tests := []struct {
+		want string
+		opts []Option
+	}{
+		{"missing name", []Option{WithCommand("a.exe"), WithTriggers([]string{"startup"})}},
+		{"missing command", []Option{WithName("a"), WithTriggers([]string{"startup"})}},
+		{"missing trigger", []Option{WithName("a"), WithCommand("a.exe")}},
+		{"invalid trigger daily@", []Option{WithName("a"), WithCommand("a.exe"), WithTriggers([]string{"startup", "daily@"})}},
+	}
+	for _, tt := range tests {
+		task, err := NewTask(tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := task.BuildTaskXML(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("BuildTaskXML() error = %v, want %q", err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,2 @@
+@echo off
+schtasks /Create /TN "backup" /XML "%~dp0backup-task.xml" /F
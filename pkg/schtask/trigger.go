@@ -0,0 +1,140 @@
+package schtask
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 触发器格式:
+//   - daily@HH:MM[/N]            每 N 天 (默认 1) 在指定时间执行
+//   - weekly@Mon,Fri@HH:MM[/N]   每 N 周 (默认 1) 在指定星期执行
+//   - startup[/PT1M]             系统启动后执行，可指定延迟
+//   - logon[/PT1M][@DOMAIN\user] 用户登录后执行，可指定延迟和用户 (默认任意用户)
+//   - idle                       系统空闲时执行
+func addTrigger(taskXML *TaskXML, trigger string, startDate time.Time) error {
+	kind, rest, _ := strings.Cut(trigger, "@")
+	kind, delay, _ := strings.Cut(kind, "/")
+	switch strings.ToLower(kind) {
+	case "daily":
+		at, interval, err := parseInterval(rest)
+		if err != nil {
+			return fmt.Errorf("invalid trigger %s: %v", trigger, err)
+		}
+		boundary, err := startBoundary(startDate, at)
+		if err != nil {
+			return fmt.Errorf("invalid trigger %s: %v", trigger, err)
+		}
+		taskXML.Triggers.CalendarTriggers = append(taskXML.Triggers.CalendarTriggers, &CalendarTrigger{
+			StartBoundary: boundary,
+			Enabled:       true,
+			ScheduleByDay: &ScheduleByDay{DaysInterval: interval},
+		})
+	case "weekly":
+		days, timeSpec, ok := strings.Cut(rest, "@")
+		if !ok {
+			return fmt.Errorf("invalid trigger %s: missing time", trigger)
+		}
+		daysOfWeek, err := parseDaysOfWeek(days)
+		if err != nil {
+			return fmt.Errorf("invalid trigger %s: %v", trigger, err)
+		}
+		at, interval, err := parseInterval(timeSpec)
+		if err != nil {
+			return fmt.Errorf("invalid trigger %s: %v", trigger, err)
+		}
+		boundary, err := startBoundary(startDate, at)
+		if err != nil {
+			return fmt.Errorf("invalid trigger %s: %v", trigger, err)
+		}
+		taskXML.Triggers.CalendarTriggers = append(taskXML.Triggers.CalendarTriggers, &CalendarTrigger{
+			StartBoundary:  boundary,
+			Enabled:        true,
+			ScheduleByWeek: &ScheduleByWeek{DaysOfWeek: daysOfWeek, WeeksInterval: interval},
+		})
+	case "startup":
+		taskXML.Triggers.BootTriggers = append(taskXML.Triggers.BootTriggers, &BootTrigger{Enabled: true, Delay: delay})
+	case "logon":
+		taskXML.Triggers.LogonTriggers = append(taskXML.Triggers.LogonTriggers, &LogonTrigger{Enabled: true, UserId: rest, Delay: delay})
+	case "idle":
+		taskXML.Triggers.IdleTriggers = append(taskXML.Triggers.IdleTriggers, &IdleTrigger{Enabled: true})
+		taskXML.Settings.IdleSettings = &IdleSettings{Duration: "PT10M", WaitTimeout: "PT1H"}
+	default:
+		return fmt.Errorf("invalid trigger %s", trigger)
+	}
+	return nil
+}
+
+// parseInterval 解析 HH:MM[/N]
+func parseInterval(s string) (string, int, error) {
+	at, intervalStr, ok := strings.Cut(s, "/")
+	interval := 1
+	if ok {
+		n, err := strconv.Atoi(intervalStr)
+		if err != nil || n < 1 {
+			return "", 0, fmt.Errorf("invalid interval %s", intervalStr)
+		}
+		interval = n
+	}
+	if at == "" {
+		return "", 0, fmt.Errorf("missing time")
+	}
+	return at, interval, nil
+}
+
+// startBoundary 返回开始日期加指定时间，格式为 2006-01-02T15:04:05
+func startBoundary(startDate time.Time, at string) (string, error) {
+	clock, err := time.Parse("15:04", at)
+	if err != nil {
+		return "", fmt.Errorf("invalid time %s", at)
+	}
+	boundary := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), clock.Hour(), clock.Minute(), 0, 0, time.Local)
+	return boundary.Format("2006-01-02T15:04:05"), nil
+}
+
+func parseDaysOfWeek(s string) (*DaysOfWeek, error) {
+	d := &DaysOfWeek{}
+	days := map[string]**struct{}{
+		"mon": &d.Monday,
+		"tue": &d.Tuesday,
+		"wed": &d.Wednesday,
+		"thu": &d.Thursday,
+		"fri": &d.Friday,
+		"sat": &d.Saturday,
+		"sun": &d.Sunday,
+	}
+	for _, day := range strings.Split(s, ",") {
+		key := strings.ToLower(strings.TrimSpace(day))
+		if len(key) > 3 {
+			key = key[:3]
+		}
+		field, ok := days[key]
+		if !ok {
+			return nil, fmt.Errorf("invalid day of week %s", day)
+		}
+		*field = &struct{}{}
+	}
+	return d, nil
+}
+
+// isoDuration 将时长转换为 ISO 8601 格式，例如 PT1H30M
+func isoDuration(d time.Duration) string {
+	if d <= 0 {
+		return "PT0S"
+	}
+	var b strings.Builder
+	b.WriteString("PT")
+	if h := int(d.Hours()); h > 0 {
+		fmt.Fprintf(&b, "%dH", h)
+		d -= time.Duration(h) * time.Hour
+	}
+	if m := int(d.Minutes()); m > 0 {
+		fmt.Fprintf(&b, "%dM", m)
+		d -= time.Duration(m) * time.Minute
+	}
+	if s := int(d.Seconds()); s > 0 {
+		fmt.Fprintf(&b, "%dS", s)
+	}
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package schtask
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var testStartDate = time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+
+func TestAddTrigger(t *testing.T) {
+	tests := []struct {
+		trigger string
+		check   func(t *testing.T, taskXML *TaskXML)
+	}{
+		{"daily@02:30", func(t *testing.T, taskXML *TaskXML) {
+			c := taskXML.Triggers.CalendarTriggers[0]
+			if c.StartBoundary != "2024-03-01T02:30:00" || c.ScheduleByDay == nil || c.ScheduleByDay.DaysInterval != 1 {
+				t.Fatalf("trigger = %+v, %+v", c, c.ScheduleByDay)
+			}
+		}},
+		{"DAILY@23:05/3", func(t *testing.T, taskXML *TaskXML) {
+			c := taskXML.Triggers.CalendarTriggers[0]
+			if c.StartBoundary != "2024-03-01T23:05:00" || c.ScheduleByDay.DaysInterval != 3 {
+				t.Fatalf("trigger = %+v, %+v", c, c.ScheduleByDay)
+			}
+		}},
+		{"weekly@Mon,friday@08:00/2", func(t *testing.T, taskXML *TaskXML) {
+			c := taskXML.Triggers.CalendarTriggers[0]
+			w := c.ScheduleByWeek
+			if c.StartBoundary != "2024-03-01T08:00:00" || w == nil || w.WeeksInterval != 2 {
+				t.Fatalf("trigger = %+v, %+v", c, w)
+			}
+			d := w.DaysOfWeek
+			if d.Monday == nil || d.Friday == nil || d.Tuesday != nil || d.Sunday != nil {
+				t.Fatalf("days of week = %+v", d)
+			}
+		}},
+		{"startup", func(t *testing.T, taskXML *TaskXML) {
+			if b := taskXML.Triggers.BootTriggers[0]; !b.Enabled || b.Delay != "" {
+				t.Fatalf("trigger = %+v", b)
+			}
+		}},
+		{"startup/PT1M", func(t *testing.T, taskXML *TaskXML) {
+			if b := taskXML.Triggers.BootTriggers[0]; b.Delay != "PT1M" {
+				t.Fatalf("trigger = %+v", b)
+			}
+		}},
+		{"logon", func(t *testing.T, taskXML *TaskXML) {
+			if l := taskXML.Triggers.LogonTriggers[0]; !l.Enabled || l.UserId != "" || l.Delay != "" {
+				t.Fatalf("trigger = %+v", l)
+			}
+		}},
+		{`logon/PT30S@CORP\alice`, func(t *testing.T, taskXML *TaskXML) {
+			if l := taskXML.Triggers.LogonTriggers[0]; l.UserId != `CORP\alice` || l.Delay != "PT30S" {
+				t.Fatalf("trigger = %+v", l)
+			}
+		}},
+		{"idle", func(t *testing.T, taskXML *TaskXML) {
+			if len(taskXML.Triggers.IdleTriggers) != 1 || taskXML.Settings.IdleSettings == nil {
+				t.Fatalf("triggers = %+v, settings = %+v", taskXML.Triggers, taskXML.Settings)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.trigger, func(t *testing.T) {
+			taskXML := &TaskXML{Triggers: &Triggers{}, Settings: &Settings{}}
+			if err := addTrigger(taskXML, tt.trigger, testStartDate); err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, taskXML)
+		})
+	}
+}
+
+func TestAddTriggerInvalid(t *testing.T) {
+	tests := []struct {
+		trigger string
+		want    string
+	}{
+		{"", "invalid trigger"},
+		{"hourly@10:00", "invalid trigger hourly@10:00"},
+		{"daily", "missing time"},
+		{"daily@", "missing time"},
+		{"daily@25:00", "invalid time 25:00"},
+		{"daily@9am", "invalid time 9am"},
+		{"daily@10:00/0", "invalid interval 0"},
+		{"daily@10:00/x", "invalid interval x"},
+		{"weekly@Mon", "missing time"},
+		{"weekly@Mon,Funday@10:00", "invalid day of week Funday"},
+		{"weekly@@10:00", "invalid day of week"},
+		{"weekly@Mon@10:00/-1", "invalid interval -1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.trigger, func(t *testing.T) {
+			taskXML := &TaskXML{Triggers: &Triggers{}, Settings: &Settings{}}
+			err := addTrigger(taskXML, tt.trigger, testStartDate)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("addTrigger(%q) error = %v, want %q", tt.trigger, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsoDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "PT0S"},
+		{-time.Second, "PT0S"},
+		{30 * time.Second, "PT30S"},
+		{5 * time.Minute, "PT5M"},
+		{72 * time.Hour, "PT72H"},
+		{90*time.Minute + 15*time.Second, "PT1H30M15S"},
+	}
+	for _, tt := range tests {
+		if got := isoDuration(tt.d); got != tt.want {
+			t.Errorf("isoDuration(%s) = %s, want %s", tt.d, got, tt.want)
+		}
+	}
+}
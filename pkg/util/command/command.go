@@ -2,7 +2,9 @@ package command
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
+	"runtime"
 	"strings"
 )
 
@@ -20,3 +22,24 @@ func RunCommand(args ...string) (string, error) {
 	}
 	return string(out), nil
 }
+
+// RunCommandEnv 在 dir 中执行命令，env 追加到当前环境变量之后。
+// 不输出到终端，返回合并后的标准输出和标准错误输出，执行失败时同样返回已有的输出。
+func RunCommandEnv(dir string, env []string, args ...string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("empty command")
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+// ShellArgs 返回通过系统 shell 执行 line 的参数，Windows 使用 cmd /C，其他系统使用 sh -c
+func ShellArgs(line string) []string {
+	if runtime.GOOS == "windows" {
+		return []string{"cmd", "/C", line}
+	}
+	return []string{"sh", "-c", line}
+}
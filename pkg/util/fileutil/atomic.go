@@ -0,0 +1,46 @@
+package fileutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic 先写入同目录下的临时文件，再重命名覆盖目标文件。
+// 临时文件与目标文件在同一目录，避免跨卷重命名失败；目标文件已存在时保留其权限。
+func WriteFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	if info, err := os.Stat(filename); err == nil {
+		perm = info.Mode().Perm()
+	}
+	tempFile, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	tempName := tempFile.Name()
+	// 任何一步失败都删除临时文件
+	success := false
+	defer func() {
+		if !success {
+			_ = tempFile.Close()
+			_ = os.Remove(tempName)
+		}
+	}()
+
+	if _, err := tempFile.Write(data); err != nil {
+		return fmt.Errorf("写入临时文件失败: %v", err)
+	}
+	if err := tempFile.Sync(); err != nil {
+		return fmt.Errorf("同步临时文件失败: %v", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("关闭临时文件失败: %v", err)
+	}
+	if err := os.Chmod(tempName, perm); err != nil {
+		return fmt.Errorf("设置文件权限失败: %v", err)
+	}
+	if err := os.Rename(tempName, filename); err != nil {
+		return fmt.Errorf("替换文件失败: %v", err)
+	}
+	success = true
+	return nil
+}
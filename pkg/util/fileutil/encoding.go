@@ -0,0 +1,129 @@
+package fileutil
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// TextEncoding 文本文件的编码
+type TextEncoding string
+
+const (
+	UTF8    TextEncoding = "utf-8"
+	UTF8BOM TextEncoding = "utf-8-bom"
+	UTF16LE TextEncoding = "utf-16le"
+	UTF16BE TextEncoding = "utf-16be"
+	// ANSI 非 UTF-8 的本地代码页，按 GBK (代码页 936) 转换
+	ANSI TextEncoding = "ansi"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// DetectEncoding 根据 BOM 和内容检测文本编码
+func DetectEncoding(data []byte) TextEncoding {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return UTF8BOM
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return UTF16LE
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return UTF16BE
+	}
+	// 没有 BOM 的 UTF-16：ASCII 字符的高字节为 0，中文等字符的低字节也可能为 0，
+	// 因此只要求高字节位置的 0 明显多于低字节位置
+	if len(data) >= 2 && len(data)%2 == 0 {
+		var even, odd int
+		for i := 0; i < len(data); i += 2 {
+			if data[i] == 0 {
+				even++
+			}
+			if data[i+1] == 0 {
+				odd++
+			}
+		}
+		half := len(data) / 2
+		if odd > even*2 && odd*16 >= half {
+			return UTF16LE
+		}
+		if even > odd*2 && even*16 >= half {
+			return UTF16BE
+		}
+	}
+	if utf8.Valid(data) {
+		return UTF8
+	}
+	return ANSI
+}
+
+func utf16Encoding(enc TextEncoding, bom unicode.BOMPolicy) encoding.Encoding {
+	if enc == UTF16BE {
+		return unicode.UTF16(unicode.BigEndian, bom)
+	}
+	return unicode.UTF16(unicode.LittleEndian, bom)
+}
+
+// DecodeText 检测编码并转换为 UTF-8，返回去除 BOM 后的内容
+func DecodeText(data []byte) ([]byte, TextEncoding, error) {
+	enc := DetectEncoding(data)
+	switch enc {
+	case UTF8BOM:
+		return data[len(bomUTF8):], enc, nil
+	case UTF16LE, UTF16BE:
+		text, err := utf16Encoding(enc, unicode.ExpectBOM).NewDecoder().Bytes(data)
+		if err != nil {
+			// 没有 BOM
+			text, err = utf16Encoding(enc, unicode.IgnoreBOM).NewDecoder().Bytes(data)
+		}
+		if err != nil {
+			return nil, enc, fmt.Errorf("%s 解码失败: %v", enc, err)
+		}
+		return text, enc, nil
+	case ANSI:
+		text, err := simplifiedchinese.GBK.NewDecoder().Bytes(data)
+		if err != nil {
+			return nil, enc, fmt.Errorf("%s 解码失败: %v", enc, err)
+		}
+		return text, enc, nil
+	}
+	return data, enc, nil
+}
+
+// EncodeText 将 UTF-8 内容按原编码写回，hasBOM 表示原文件是否有 BOM。
+// ANSI 文件中无法用 GBK 表示的字符会返回错误，不会写入乱码
+func EncodeText(text []byte, enc TextEncoding, hasBOM bool) ([]byte, error) {
+	switch enc {
+	case UTF8BOM:
+		return append(append([]byte{}, bomUTF8...), text...), nil
+	case UTF16LE, UTF16BE:
+		bom := unicode.IgnoreBOM
+		if hasBOM {
+			bom = unicode.UseBOM
+		}
+		data, err := utf16Encoding(enc, bom).NewEncoder().Bytes(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s 编码失败: %v", enc, err)
+		}
+		return data, nil
+	case ANSI:
+		data, err := simplifiedchinese.GBK.NewEncoder().Bytes(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s 编码失败，内容包含 GBK 无法表示的字符: %v", enc, err)
+		}
+		return data, nil
+	}
+	return text, nil
+}
+
+// HasBOM 判断内容是否以 BOM 开头
+func HasBOM(data []byte) bool {
+	return bytes.HasPrefix(data, bomUTF8) || bytes.HasPrefix(data, bomUTF16LE) || bytes.HasPrefix(data, bomUTF16BE)
+}
@@ -0,0 +1,71 @@
+package fileutil
+
+import (
+	"bytes"
+	"testing"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func TestEncodingRoundTrip(t *testing.T) {
+	const text = "[Setup]\r\nAppName=示例程序\r\nAppVersion=1.0.0\r\n"
+	utf16le := func(bom unicode.BOMPolicy) []byte {
+		data, _ := unicode.UTF16(unicode.LittleEndian, bom).NewEncoder().Bytes([]byte(text))
+		return data
+	}
+	utf16be := func(bom unicode.BOMPolicy) []byte {
+		data, _ := unicode.UTF16(unicode.BigEndian, bom).NewEncoder().Bytes([]byte(text))
+		return data
+	}
+	gbk, _ := simplifiedchinese.GBK.NewEncoder().Bytes([]byte(text))
+
+	tests := []struct {
+		name string
+		data []byte
+		want TextEncoding
+	}{
+		{"utf-8", []byte(text), UTF8},
+		{"utf-8 bom", append(append([]byte{}, bomUTF8...), text...), UTF8BOM},
+		{"utf-16le bom", utf16le(unicode.UseBOM), UTF16LE},
+		{"utf-16le", utf16le(unicode.IgnoreBOM), UTF16LE},
+		{"utf-16be bom", utf16be(unicode.UseBOM), UTF16BE},
+		{"utf-16be", utf16be(unicode.IgnoreBOM), UTF16BE},
+		{"gbk", gbk, ANSI},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, enc, err := DecodeText(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if enc != tt.want {
+				t.Fatalf("encoding = %s, want %s", enc, tt.want)
+			}
+			if string(decoded) != text {
+				t.Fatalf("decoded = %q, want %q", decoded, text)
+			}
+			encoded, err := EncodeText(decoded, enc, HasBOM(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(encoded, tt.data) {
+				t.Fatalf("encoded = % x, want % x", encoded, tt.data)
+			}
+		})
+	}
+}
+
+func TestDetectEncodingUTF16CJK(t *testing.T) {
+	// 中文字符的低字节可能为 0，如 "一" (U+4E00)
+	data, _ := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewEncoder().Bytes([]byte("名称=一\r\n"))
+	if enc := DetectEncoding(data); enc != UTF16LE {
+		t.Fatalf("DetectEncoding() = %s, want %s", enc, UTF16LE)
+	}
+}
+
+func TestEncodeTextANSIUnsupported(t *testing.T) {
+	if _, err := EncodeText([]byte("emoji 😀"), ANSI, false); err == nil {
+		t.Fatal("EncodeText() should refuse characters GBK cannot represent")
+	}
+}
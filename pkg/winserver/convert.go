@@ -0,0 +1,315 @@
+package winserver
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 支持转换的来源格式
+const (
+	ConvertFromCompose  = "compose"
+	ConvertFromProcfile = "procfile"
+)
+
+// ConvertResult 转换结果，Warnings 记录无法转换的内容
+type ConvertResult struct {
+	Services []*Server
+	Warnings []string
+}
+
+func (r *ConvertResult) warn(service string, format string, a ...any) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf("%s: %s", service, fmt.Sprintf(format, a...)))
+}
+
+// Convert 将 docker-compose 或 Procfile 内容转换为服务定义
+func Convert(from string, data []byte) (*ConvertResult, error) {
+	switch from {
+	case ConvertFromCompose:
+		return ConvertCompose(data)
+	case ConvertFromProcfile:
+		return ConvertProcfile(data)
+	default:
+		return nil, fmt.Errorf("invalid convert source: %s", from)
+	}
+}
+
+// composeKeys 可以转换的 docker-compose 服务字段
+var composeKeys = map[string]bool{
+	"command":        true,
+	"entrypoint":     true,
+	"environment":    true,
+	"working_dir":    true,
+	"depends_on":     true,
+	"restart":        true,
+	"container_name": true,
+}
+
+// ConvertCompose 将 docker-compose.yml 中的服务转换为服务定义，
+// 映射 command/entrypoint、environment、working_dir、depends_on 和 restart。
+func ConvertCompose(data []byte) (*ConvertResult, error) {
+	var compose struct {
+		Services map[string]map[string]any `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(data, &compose); err != nil {
+		return nil, fmt.Errorf("解析 docker-compose 失败: %v", err)
+	}
+	if len(compose.Services) == 0 {
+		return nil, fmt.Errorf("docker-compose 中没有服务")
+	}
+
+	names := make([]string, 0, len(compose.Services))
+	for name := range compose.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := &ConvertResult{}
+	for _, name := range names {
+		service := compose.Services[name]
+
+		keys := make([]string, 0, len(service))
+		for key := range service {
+			if !composeKeys[key] {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			result.warn(name, "%s is not supported and was ignored", key)
+		}
+
+		entrypoint, err := composeCommand(service["entrypoint"])
+		if err != nil {
+			return nil, fmt.Errorf("%s: entrypoint: %v", name, err)
+		}
+		command, err := composeCommand(service["command"])
+		if err != nil {
+			return nil, fmt.Errorf("%s: command: %v", name, err)
+		}
+		args := append(entrypoint, command...)
+		if len(args) == 0 {
+			result.warn(name, "no command or entrypoint, image default command cannot be translated, service skipped")
+			continue
+		}
+
+		s := NewDefaultServer()
+		s.SId = name
+		s.SName = name
+		s.SDescription = name
+		if containerName, ok := service["container_name"].(string); ok && containerName != "" {
+			s.SName = containerName
+		}
+		s.SExecutable = args[0]
+		s.SArguments = JoinArgs(args[1:])
+		if strings.Contains(s.SExecutable+" "+s.SArguments, "$") {
+			result.warn(name, "command uses variable interpolation, check the generated arguments")
+		}
+
+		if workingDir, ok := service["working_dir"].(string); ok {
+			s.SWorkingDirectory = workingDir
+		}
+
+		env, err := composeEnvironment(service["environment"])
+		if err != nil {
+			return nil, fmt.Errorf("%s: environment: %v", name, err)
+		}
+		for _, e := range env {
+			if !strings.Contains(e, "=") {
+				result.warn(name, "environment %s has no value and was ignored", e)
+				continue
+			}
+			s.SEnv = append(s.SEnv, e)
+		}
+
+		depends, err := composeDependsOn(service["depends_on"])
+		if err != nil {
+			return nil, fmt.Errorf("%s: depends_on: %v", name, err)
+		}
+		s.SDepends = depends
+
+		if restart, ok := service["restart"].(string); ok {
+			switch {
+			case restart == "no":
+				s.SFailure = "none"
+			case restart == "always", restart == "unless-stopped", strings.HasPrefix(restart, "on-failure"):
+				s.SFailure = "restart"
+			default:
+				result.warn(name, "restart policy %s is not supported", restart)
+			}
+		}
+		result.Services = append(result.Services, s)
+	}
+	return result, nil
+}
+
+// composeCommand 解析字符串或列表形式的 command/entrypoint
+func composeCommand(value any) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return SplitArgs(v), nil
+	case []any:
+		args := make([]string, 0, len(v))
+		for _, item := range v {
+			args = append(args, fmt.Sprint(item))
+		}
+		return args, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %T", value)
+	}
+}
+
+// composeEnvironment 解析 map 或列表形式的 environment，返回 KEY=VALUE 列表
+func composeEnvironment(value any) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		env := make([]string, 0, len(v))
+		for _, item := range v {
+			env = append(env, fmt.Sprint(item))
+		}
+		return env, nil
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		env := make([]string, 0, len(v))
+		for _, key := range keys {
+			if v[key] == nil {
+				env = append(env, key)
+				continue
+			}
+			env = append(env, fmt.Sprintf("%s=%v", key, v[key]))
+		}
+		return env, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %T", value)
+	}
+}
+
+// composeDependsOn 解析列表或 map 形式的 depends_on
+func composeDependsOn(value any) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		depends := make([]string, 0, len(v))
+		for _, item := range v {
+			depends = append(depends, fmt.Sprint(item))
+		}
+		return depends, nil
+	case map[string]any:
+		depends := make([]string, 0, len(v))
+		for key := range v {
+			depends = append(depends, key)
+		}
+		sort.Strings(depends)
+		return depends, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %T", value)
+	}
+}
+
+var (
+	procfileLinePattern = regexp.MustCompile(`^([A-Za-z0-9_-]+):\s*(.+)$`)
+	shellSyntaxPattern  = regexp.MustCompile(`&&|\|\||[|<>;$]`)
+)
+
+// ConvertProcfile 将 Procfile 中的每个进程转换为服务定义，
+// 使用 shell 语法的命令会通过 cmd /c 执行。
+func ConvertProcfile(data []byte) (*ConvertResult, error) {
+	result := &ConvertResult{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		matches := procfileLinePattern.FindStringSubmatch(line)
+		if matches == nil {
+			result.warn(fmt.Sprintf("line %d", lineNo), "invalid process line and was ignored")
+			continue
+		}
+		name, command := matches[1], strings.TrimSpace(matches[2])
+
+		s := NewDefaultServer()
+		s.SId = name
+		s.SName = name
+		s.SDescription = name
+		if shellSyntaxPattern.MatchString(command) {
+			result.warn(name, "command uses shell syntax, wrapped with cmd /c")
+			s.SExecutable = "cmd"
+			s.SArguments = "/c " + command
+		} else {
+			args := SplitArgs(command)
+			s.SExecutable = args[0]
+			s.SArguments = JoinArgs(args[1:])
+		}
+		result.Services = append(result.Services, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取 Procfile 失败: %v", err)
+	}
+	if len(result.Services) == 0 {
+		return nil, fmt.Errorf("Procfile 中没有进程")
+	}
+	return result, nil
+}
+
+// SplitArgs 按空白拆分命令行，支持单引号和双引号
+func SplitArgs(command string) []string {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg := false
+	for _, r := range command {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args
+}
+
+// JoinArgs 拼接参数，包含空白的参数使用双引号包裹
+func JoinArgs(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t") {
+			arg = `"` + arg + `"`
+		}
+		quoted = append(quoted, arg)
+	}
+	return strings.Join(quoted, " ")
+}
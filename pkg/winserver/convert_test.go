@@ -0,0 +1,60 @@
+package winserver
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvertComposeWorkingDirAndRestart(t *testing.T) {
+	compose := `
+services:
+  api:
+    command: ["api.exe", "--port", "8080"]
+    working_dir: C:\apps\api
+    restart: always
+    ports: ["8080:8080"]
+  worker:
+    command: worker.exe
+    restart: "no"
+  db:
+    image: postgres
+`
+	result, err := ConvertCompose([]byte(compose))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Services) != 2 {
+		t.Fatalf("got %d services, want 2 (db has no command)", len(result.Services))
+	}
+	api, worker := result.Services[0], result.Services[1]
+	if api.SName != "api" || api.SWorkingDirectory != `C:\apps\api` || api.SFailure != "restart" {
+		t.Errorf("api = %+v", api)
+	}
+	if worker.SFailure != "none" {
+		t.Errorf("worker failure = %q, want none", worker.SFailure)
+	}
+	want := []string{"api: ports is not supported and was ignored", "db: image is not supported and was ignored"}
+	for _, w := range want {
+		if !strings.Contains(strings.Join(result.Warnings, "\n"), w) {
+			t.Errorf("warnings %q missing %q", result.Warnings, w)
+		}
+	}
+
+	serverXML, err := api.BuildServerXML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := serverXML.ToXML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []string{
+		`<workingdirectory>C:\apps\api</workingdirectory>`,
+		`<onfailure action="restart"></onfailure>`,
+		`<arguments>--port 8080</arguments>`,
+	} {
+		if !strings.Contains(out, w) {
+			t.Errorf("xml missing %s:\n%s", w, out)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package winserver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gookit/goutil/fsutil"
+)
+
+var openPortPattern = regexp.MustCompile(`^(?i)(tcp|udp):(\d+)(?:-(\d+))?$`)
+
+// OpenPort 入站防火墙规则，端口可以是单个端口或端口范围
+type OpenPort struct {
+	Protocol string
+	Port     string
+}
+
+// ParseOpenPort 解析 tcp:9000 或 udp:8000-8010 格式的端口声明
+func ParseOpenPort(s string) (*OpenPort, error) {
+	matches := openPortPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if matches == nil {
+		return nil, fmt.Errorf("invalid open port %s, expected tcp:9000 or udp:8000-8010", s)
+	}
+	ports := []string{matches[2]}
+	if matches[3] != "" {
+		ports = append(ports, matches[3])
+	}
+	numbers := make([]int, 0, len(ports))
+	for _, port := range ports {
+		n, err := strconv.Atoi(port)
+		if err != nil || n < 1 || n > 65535 {
+			return nil, fmt.Errorf("invalid open port %s, port out of range", s)
+		}
+		numbers = append(numbers, n)
+	}
+	if len(numbers) == 2 && numbers[0] > numbers[1] {
+		return nil, fmt.Errorf("invalid open port %s, range start is greater than end", s)
+	}
+	port := matches[2]
+	if matches[3] != "" {
+		port += "-" + matches[3]
+	}
+	return &OpenPort{Protocol: strings.ToUpper(matches[1]), Port: port}, nil
+}
+
+// RuleName 防火墙规则名称
+func (p *OpenPort) RuleName(service string) string {
+	return fmt.Sprintf("%s %s %s", service, p.Protocol, p.Port)
+}
+
+// openPorts 解析服务声明的全部端口
+func (s *Server) openPorts() ([]*OpenPort, error) {
+	var ports []*OpenPort
+	for _, item := range s.SOpenPorts {
+		if item == "" {
+			continue
+		}
+		port, err := ParseOpenPort(item)
+		if err != nil {
+			return nil, err
+		}
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
+
+// FirewallScripts 返回防火墙脚本文件名和内容，包括 netsh 和 PowerShell 的添加及删除脚本
+func (s *Server) FirewallScripts() (map[string]string, error) {
+	ports, err := s.openPorts()
+	if err != nil {
+		return nil, err
+	}
+	if len(ports) == 0 {
+		return nil, nil
+	}
+
+	netshAdd := []string{"@echo off"}
+	netshRemove := []string{"@echo off"}
+	psAdd := []string{`$ErrorActionPreference = "Stop"`}
+	psRemove := []string{}
+	for _, p := range ports {
+		name := p.RuleName(s.SName)
+		netshAdd = append(netshAdd,
+			fmt.Sprintf(`netsh advfirewall firewall delete rule name="%s" >nul 2>&1`, name),
+			fmt.Sprintf(`netsh advfirewall firewall add rule name="%s" dir=in action=allow protocol=%s localport=%s`, name, p.Protocol, p.Port),
+		)
+		netshRemove = append(netshRemove,
+			fmt.Sprintf(`netsh advfirewall firewall delete rule name="%s"`, name),
+		)
+		psAdd = append(psAdd,
+			fmt.Sprintf(`Remove-NetFirewallRule -DisplayName "%s" -ErrorAction SilentlyContinue`, name),
+			fmt.Sprintf(`New-NetFirewallRule -DisplayName "%s" -Direction Inbound -Action Allow -Protocol %s -LocalPort %s | Out-Null`, name, p.Protocol, p.Port),
+		)
+		psRemove = append(psRemove,
+			fmt.Sprintf(`Remove-NetFirewallRule -DisplayName "%s" -ErrorAction SilentlyContinue`, name),
+		)
+	}
+
+	join := func(lines []string) string {
+		return strings.Join(lines, "\r\n") + "\r\n"
+	}
+	return map[string]string{
+		fmt.Sprintf("%s-firewall-add.bat", s.SName):    join(netshAdd),
+		fmt.Sprintf("%s-firewall-remove.bat", s.SName): join(netshRemove),
+		fmt.Sprintf("%s-firewall-add.ps1", s.SName):    join(psAdd),
+		fmt.Sprintf("%s-firewall-remove.ps1", s.SName): join(psRemove),
+	}, nil
+}
+
+// GenerateFirewallScripts 在服务输出目录生成防火墙脚本，未声明端口时不生成
+func (s *Server) GenerateFirewallScripts() error {
+	scripts, err := s.FirewallScripts()
+	if err != nil {
+		return err
+	}
+	for name, content := range scripts {
+		filename := filepath.Join(s.BasePath, name)
+		if fsutil.FileExist(filename) && !s.sForce {
+			return fmt.Errorf("防火墙脚本 %s 已存在", filename)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+			return fmt.Errorf("写入防火墙脚本失败。%v", err)
+		}
+	}
+	return nil
+}
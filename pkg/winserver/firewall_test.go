@@ -0,0 +1,16 @@
+package winserver
+
+import "testing"
+
+func TestParseOpenPort(t *testing.T) {
+	for _, s := range []string{"tcp:9000", "UDP:8000-8010", "tcp:8080-8080"} {
+		if _, err := ParseOpenPort(s); err != nil {
+			t.Errorf("ParseOpenPort(%q) error: %v", s, err)
+		}
+	}
+	for _, s := range []string{"tcp:9000-8000", "tcp:0", "udp:8000-70000", "http:80"} {
+		if _, err := ParseOpenPort(s); err == nil {
+			t.Errorf("ParseOpenPort(%q) should fail", s)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+// gen 在当前目录生成 schema_docs.go，参数为 Schema 的根类型，由 pkg/winserver 中的 go:generate 调用
+package main
+
+import (
+	"log"
+	"os"
+
+	"win_helper/pkg/winserver/internal/schemadoc"
+)
+
+func main() {
+	pkg := os.Getenv("GOPACKAGE")
+	if pkg == "" {
+		pkg = "winserver"
+	}
+	if len(os.Args) < 2 {
+		log.Fatal("usage: gen <type>...")
+	}
+	if err := schemadoc.Write(".", pkg, os.Args[1:]...); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,141 @@
+// Package schemadoc 从结构体字段注释生成 JSON Schema 的字段说明
+package schemadoc
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// OutputFile 生成的文件名
+const OutputFile = "schema_docs.go"
+
+// Docs 读取 dir 中 (不含测试和生成的文件) 从 roots 可以到达的结构体的字段注释，键为 "类型名.字段名"。
+// 注释第一行只有字段名时 (如 "// Executable") 跳过该行。
+func Docs(dir string, roots ...string) (map[string]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	structs := map[string]*ast.StructType{}
+	for _, filename := range files {
+		if strings.HasSuffix(filename, "_test.go") || filepath.Base(filename) == OutputFile {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			spec, ok := n.(*ast.TypeSpec)
+			if !ok {
+				return true
+			}
+			if st, ok := spec.Type.(*ast.StructType); ok {
+				structs[spec.Name.Name] = st
+			}
+			return false
+		})
+	}
+
+	docs := map[string]string{}
+	seen := map[string]bool{}
+	queue := append([]string{}, roots...)
+	for len(queue) > 0 {
+		typeName := queue[0]
+		queue = queue[1:]
+		st, ok := structs[typeName]
+		if !ok {
+			return nil, fmt.Errorf("struct %s not found in %s", typeName, dir)
+		}
+		seen[typeName] = true
+		for _, field := range st.Fields.List {
+			if ref := localType(field.Type); ref != "" && structs[ref] != nil && !seen[ref] {
+				seen[ref] = true
+				queue = append(queue, ref)
+			}
+			text := fieldDoc(field)
+			for _, name := range field.Names {
+				if doc := trimFieldName(text, name.Name); doc != "" && name.IsExported() {
+					docs[typeName+"."+name.Name] = doc
+				}
+			}
+		}
+	}
+	return docs, nil
+}
+
+// localType 返回字段引用的包内类型名，支持指针、切片和数组
+func localType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return localType(t.X)
+	case *ast.ArrayType:
+		return localType(t.Elt)
+	}
+	return ""
+}
+
+func fieldDoc(field *ast.Field) string {
+	if field.Doc != nil {
+		return field.Doc.Text()
+	}
+	if field.Comment != nil {
+		return field.Comment.Text()
+	}
+	return ""
+}
+
+func trimFieldName(text string, name string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if strings.EqualFold(strings.TrimSpace(lines[0]), name) {
+		lines = lines[1:]
+	}
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// Generate 生成 schema_docs.go 的内容
+func Generate(dir string, pkg string, roots ...string) ([]byte, error) {
+	docs, err := Docs(dir, roots...)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(docs))
+	for key := range docs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by go run ./internal/schemadoc/gen %s; DO NOT EDIT.\n\n", strings.Join(roots, " "))
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	b.WriteString("// schemaDocs 字段说明，键为 \"类型名.字段名\"，由字段注释生成\n")
+	b.WriteString("var schemaDocs = map[string]string{\n")
+	for _, key := range keys {
+		fmt.Fprintf(&b, "\t%q: %q,\n", key, docs[key])
+	}
+	b.WriteString("}\n")
+	return format.Source(b.Bytes())
+}
+
+// Write 生成并写入 dir 中的 schema_docs.go
+func Write(dir string, pkg string, roots ...string) error {
+	data, err := Generate(dir, pkg, roots...)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, OutputFile), data, 0o644)
+}
@@ -0,0 +1,334 @@
+package winserver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gookit/goutil/fsutil"
+)
+
+// 检查问题级别
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+)
+
+// DefaultPortPatterns 默认的监听端口提取规则，第一个分组为端口号。
+// 只匹配监听类参数 (--port、-p、server.port、--listen) 和 PORT 环境变量，端口冲突报告为错误。
+var DefaultPortPatterns = []string{
+	`(?i)(?:^|\s)--?port[= ](\d+)\b`,
+	`(?:^|\s)-p[= ]?(\d+)\b`,
+	`(?i)(?:^|\s)(?:-D|--)?server\.port[= ](\d+)\b`,
+	`(?i)(?:^|\s)--?listen(?:-addr(?:ess)?)?[= ](?:\S*:)?(\d+)\b`,
+	`(?i)^(?:server_|listen_)?port=(\d+)$`,
+}
+
+// LoosePortPatterns 宽松的端口提取规则，也会匹配连接其他服务的配置 (如 DB_PORT=3306、jdbc:mysql://db:3306)，
+// 只在没有自定义规则时使用，冲突报告为警告
+var LoosePortPatterns = []string{
+	`(?i)port=(\d+)`,
+	`:(\d{2,5})\b`,
+}
+
+// LintTarget 待检查的服务配置
+type LintTarget struct {
+	// Source 配置来源，XML 文件路径或清单中的服务名
+	Source string
+	// Dir 解析相对路径时使用的目录
+	Dir string
+	XML *ServerXML
+	// OpenPorts 清单中声明的防火墙端口，视为服务监听的端口
+	OpenPorts []string
+}
+
+// LintIssue 检查发现的问题
+type LintIssue struct {
+	Level   string
+	Kind    string
+	Message string
+	Sources []string
+}
+
+func (i *LintIssue) String() string {
+	return fmt.Sprintf("%-7s %-22s %s [%s]", i.Level, i.Kind, i.Message, strings.Join(i.Sources, ", "))
+}
+
+// LoadLintTargets 读取 XML 文件或目录下全部 WinSW XML 配置，非服务配置的 XML 文件会被忽略
+func LoadLintTargets(paths []string) ([]*LintTarget, error) {
+	var files []string
+	for _, p := range paths {
+		if fsutil.IsDir(p) {
+			err := filepath.WalkDir(p, func(p string, d os.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if !d.IsDir() && strings.EqualFold(filepath.Ext(p), ".xml") {
+					files = append(files, p)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("扫描目录失败: %v", err)
+			}
+			continue
+		}
+		files = append(files, p)
+	}
+
+	var targets []*LintTarget
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("读取文件失败: %v", err)
+		}
+		serverXML, err := (&ServerXML{}).LoadXML(string(data))
+		if err != nil {
+			continue
+		}
+		targets = append(targets, &LintTarget{Source: file, Dir: filepath.Dir(file), XML: serverXML})
+	}
+	return targets, nil
+}
+
+// ManifestLintTargets 将清单中合并环境覆盖项后的服务转换为待检查的配置
+func ManifestLintTargets(m *Manifest, profile string, dir string) ([]*LintTarget, error) {
+	servers, err := m.ResolveAll(profile)
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]*LintTarget, 0, len(servers))
+	for _, s := range servers {
+		serverXML, err := s.BuildServerXML()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", s.SName, err)
+		}
+		targets = append(targets, &LintTarget{Source: s.SName, Dir: dir, XML: serverXML, OpenPorts: s.SOpenPorts})
+	}
+	return targets, nil
+}
+
+// Linter 检查一组服务之间的冲突
+type Linter struct {
+	// PortPatterns 监听端口，冲突为错误
+	PortPatterns []*regexp.Regexp
+	// LoosePortPatterns 可能的端口，冲突为警告
+	LoosePortPatterns []*regexp.Regexp
+}
+
+// NewLinter 创建检查器，patterns 为空时使用默认的监听端口规则和宽松规则
+func NewLinter(patterns []string) (*Linter, error) {
+	l := &Linter{}
+	var err error
+	if len(patterns) == 0 {
+		if l.LoosePortPatterns, err = compilePortPatterns(LoosePortPatterns); err != nil {
+			return nil, err
+		}
+		patterns = DefaultPortPatterns
+	}
+	if l.PortPatterns, err = compilePortPatterns(patterns); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func compilePortPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port pattern %s: %v", pattern, err)
+		}
+		if re.NumSubexp() < 1 {
+			return nil, fmt.Errorf("port pattern %s must have a capture group", pattern)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// Ports 从参数和环境变量中提取监听端口
+func (l *Linter) Ports(x *ServerXML) []string {
+	return matchPorts(l.PortPatterns, x)
+}
+
+// LoosePorts 从参数和环境变量中提取可能的端口，不含监听端口
+func (l *Linter) LoosePorts(x *ServerXML) []string {
+	listen := l.Ports(x)
+	var ports []string
+	for _, port := range matchPorts(l.LoosePortPatterns, x) {
+		if !containsString(listen, port) {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+// targetPorts 返回服务监听的端口，包括清单中声明的防火墙端口。
+// TCP 端口以端口号表示，UDP 端口加 udp: 前缀，与 TCP 端口互不冲突
+func (l *Linter) targetPorts(t *LintTarget) []string {
+	ports := l.Ports(t.XML)
+	for _, item := range t.OpenPorts {
+		open, err := ParseOpenPort(item)
+		if err != nil {
+			continue
+		}
+		start, end, _ := strings.Cut(open.Port, "-")
+		first, _ := strconv.Atoi(start)
+		last := first
+		if end != "" {
+			last, _ = strconv.Atoi(end)
+		}
+		for n := first; n <= last; n++ {
+			port := strconv.Itoa(n)
+			if open.Protocol == "UDP" {
+				port = "udp:" + port
+			}
+			if !containsString(ports, port) {
+				ports = append(ports, port)
+			}
+		}
+	}
+	return ports
+}
+
+// matchPorts 按规则从参数和环境变量中提取端口
+func matchPorts(patterns []*regexp.Regexp, x *ServerXML) []string {
+	texts := []string{x.Arguments, x.StartArguments}
+	for _, e := range x.Env {
+		texts = append(texts, e.Name+"="+e.Value)
+	}
+	seen := map[string]bool{}
+	var ports []string
+	for _, text := range texts {
+		for _, re := range patterns {
+			for _, match := range re.FindAllStringSubmatch(text, -1) {
+				port := strings.TrimLeft(match[1], "0")
+				if port == "" || seen[port] {
+					continue
+				}
+				seen[port] = true
+				ports = append(ports, port)
+			}
+		}
+	}
+	return ports
+}
+
+// Lint 检查重复的 ID 和名称、共享的日志目录、缺失的可执行文件和端口冲突
+func (l *Linter) Lint(targets []*LintTarget) []*LintIssue {
+	var issues []*LintIssue
+
+	ids := newLintGroup()
+	names := newLintGroup()
+	logPaths := newLintGroup()
+	ports := newLintGroup()
+	loosePorts := newLintGroup()
+	for _, t := range targets {
+		ids.add(t.XML.Id, t.Source)
+		if t.XML.Name != "" {
+			names.add(t.XML.Name, t.Source)
+		}
+		if t.XML.Log == nil || t.XML.Log.Mode != "none" {
+			logPaths.add(resolvePath(t.Dir, t.XML.LogPath), t.Source)
+		}
+		for _, port := range l.targetPorts(t) {
+			ports.add(port, t.Source)
+			loosePorts.add(port, t.Source)
+		}
+		for _, port := range l.LoosePorts(t.XML) {
+			loosePorts.add(port, t.Source)
+		}
+		if executable := t.XML.Executable; isCheckablePath(executable) {
+			dir := t.Dir
+			if t.XML.WorkingDirectory != "" {
+				dir = resolvePath(t.Dir, t.XML.WorkingDirectory)
+			}
+			if !fsutil.FileExist(resolvePath(dir, executable)) {
+				issues = append(issues, &LintIssue{
+					Level:   LintError,
+					Kind:    "missing-executable",
+					Message: fmt.Sprintf("executable %s not found", executable),
+					Sources: []string{t.Source},
+				})
+			}
+		}
+	}
+
+	issues = append(issues, ids.duplicates(LintError, "duplicate-id", "id %s is used by %d services")...)
+	issues = append(issues, names.duplicates(LintError, "duplicate-name", "name %s is used by %d services")...)
+	issues = append(issues, logPaths.duplicates(LintWarning, "shared-log-path", "log path %s is shared by %d services")...)
+	issues = append(issues, ports.duplicates(LintError, "port-conflict", "port %s is claimed by %d services")...)
+	// 宽松规则可能匹配到连接其他服务的端口，只有不构成监听端口冲突时才报告
+	for _, key := range loosePorts.keys {
+		if len(loosePorts.sources[key]) >= 2 && len(ports.sources[key]) < 2 {
+			issues = append(issues, loosePorts.issue(key, LintWarning, "possible-port-conflict", "port %s may be claimed by %d services"))
+		}
+	}
+	return issues
+}
+
+// lintGroup 按值 (不区分大小写) 分组记录配置来源
+type lintGroup struct {
+	keys    []string
+	labels  map[string]string
+	sources map[string][]string
+}
+
+func newLintGroup() *lintGroup {
+	return &lintGroup{labels: map[string]string{}, sources: map[string][]string{}}
+}
+
+func (g *lintGroup) add(value string, source string) {
+	key := strings.ToLower(value)
+	if _, ok := g.labels[key]; !ok {
+		g.keys = append(g.keys, key)
+		g.labels[key] = value
+	}
+	g.sources[key] = append(g.sources[key], source)
+}
+
+// duplicates 返回被多个服务使用的值
+func (g *lintGroup) duplicates(level string, kind string, format string) []*LintIssue {
+	var issues []*LintIssue
+	for _, key := range g.keys {
+		if len(g.sources[key]) < 2 {
+			continue
+		}
+		issues = append(issues, g.issue(key, level, kind, format))
+	}
+	return issues
+}
+
+func (g *lintGroup) issue(key string, level string, kind string, format string) *LintIssue {
+	return &LintIssue{
+		Level:   level,
+		Kind:    kind,
+		Message: fmt.Sprintf(format, g.labels[key], len(g.sources[key])),
+		Sources: g.sources[key],
+	}
+}
+
+// isCheckablePath 判断可执行文件是否是可以检查的路径，
+// 只写文件名的从 PATH 中查找，包含环境变量的在服务运行时才能确定，均不检查。
+func isCheckablePath(executable string) bool {
+	if executable == "" || strings.Contains(executable, "%") {
+		return false
+	}
+	return strings.ContainsAny(executable, `/\`)
+}
+
+// resolvePath 将相对路径解析为相对于 dir 的路径
+func resolvePath(dir string, p string) string {
+	p = strings.ReplaceAll(p, `\`, string(filepath.Separator))
+	if p == "" {
+		return filepath.Clean(dir)
+	}
+	if filepath.IsAbs(p) || filepath.VolumeName(p) != "" || strings.Contains(p, ":") {
+		return filepath.Clean(p)
+	}
+	return filepath.Join(dir, p)
+}
@@ -0,0 +1,59 @@
+package winserver
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLinterPorts(t *testing.T) {
+	l, err := NewLinter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		args  string
+		env   []*Env
+		ports []string
+	}{
+		{args: "--port 8080", ports: []string{"8080"}},
+		{args: "-port=8080 -p 9000", ports: []string{"8080", "9000"}},
+		{args: "-Dserver.port=8080 -jar app.jar", ports: []string{"8080"}},
+		{args: "--listen 0.0.0.0:9000", ports: []string{"9000"}},
+		{args: "--listen-address=:9090", ports: []string{"9090"}},
+		{env: []*Env{{Name: "PORT", Value: "3000"}}, ports: []string{"3000"}},
+		// 连接其他服务的端口不是监听端口
+		{args: "--db jdbc:mysql://db:3306/app", ports: nil},
+		{args: "--lookupd-tcp-address=127.0.0.1:4160", ports: nil},
+		{env: []*Env{{Name: "DB_PORT", Value: "3306"}}, ports: nil},
+	} {
+		x := &ServerXML{Arguments: tt.args, Env: tt.env}
+		if got := l.Ports(x); !reflect.DeepEqual(got, tt.ports) {
+			t.Errorf("Ports(%q, %v) = %v, want %v", tt.args, tt.env, got, tt.ports)
+		}
+	}
+}
+
+func TestLintSharedDatabase(t *testing.T) {
+	l, err := NewLinter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := []*Env{{Name: "DB_PORT", Value: "3306"}}
+	targets := []*LintTarget{
+		{Source: "web", XML: &ServerXML{Id: "web", Arguments: "--port 8080", Env: db, Log: &Log{Mode: "none"}}},
+		{Source: "api", XML: &ServerXML{Id: "api", Arguments: "--port 8081", Env: db, Log: &Log{Mode: "none"}}},
+		{Source: "dns", XML: &ServerXML{Id: "dns", Log: &Log{Mode: "none"}}, OpenPorts: []string{"udp:8080", "tcp:9000-9001"}},
+		{Source: "admin", XML: &ServerXML{Id: "admin", Arguments: "--listen :9001", Log: &Log{Mode: "none"}}},
+	}
+	var kinds []string
+	for _, issue := range l.Lint(targets) {
+		kinds = append(kinds, issue.Level+" "+issue.Message)
+	}
+	want := []string{
+		"error port 9001 is claimed by 2 services",
+		"warning port 3306 may be claimed by 2 services",
+	}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Fatalf("Lint() = %v, want %v", kinds, want)
+	}
+}
@@ -0,0 +1,87 @@
+package winserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest 服务清单，一个文件中描述多个服务定义，支持 json 和 yaml 格式。
+// Profiles 按环境名和服务名记录覆盖项，解析时深度合并到基础定义上。
+type Manifest struct {
+	Services []*Server                            `json:"services" yaml:"services"`
+	Profiles map[string]map[string]map[string]any `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+}
+
+func isJsonFile(filename string) bool {
+	return strings.EqualFold(filepath.Ext(filename), ".json")
+}
+
+// LoadManifest 根据文件扩展名读取 json 或 yaml 格式的服务清单
+func LoadManifest(filename string) (*Manifest, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("读取清单文件失败: %v", err)
+	}
+	m := &Manifest{}
+	if isJsonFile(filename) {
+		err = json.Unmarshal(data, m)
+	} else {
+		err = yaml.Unmarshal(data, m)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析清单文件 %s 失败: %v", filename, err)
+	}
+	return m, nil
+}
+
+// Marshal 根据文件扩展名将清单编码为 json 或 yaml
+func (m *Manifest) Marshal(filename string) ([]byte, error) {
+	if isJsonFile(filename) {
+		data, err := json.MarshalIndent(m, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("清单编码失败: %v", err)
+		}
+		return append(data, '\n'), nil
+	}
+	data, err := yaml.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("清单编码失败: %v", err)
+	}
+	return data, nil
+}
+
+// Save 将清单保存到文件，force 为 false 时不覆盖已有文件
+func (m *Manifest) Save(filename string, force bool) error {
+	data, err := m.Marshal(filename)
+	if err != nil {
+		return err
+	}
+	flags := os.O_CREATE | os.O_TRUNC | os.O_WRONLY
+	if !force {
+		flags |= os.O_EXCL
+	}
+	file, err := os.OpenFile(filename, flags, 0o644)
+	if err != nil {
+		return fmt.Errorf("无法打开文件: %v", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("写入文件失败: %v", err)
+	}
+	return nil
+}
+
+// Find 按 ID 或名称查找服务定义
+func (m *Manifest) Find(name string) *Server {
+	for _, s := range m.Services {
+		if s.SName == name || s.SId == name {
+			return s
+		}
+	}
+	return nil
+}
@@ -1,5 +1,7 @@
 package winserver
 
+import "fmt"
+
 type Option func(s *Server) error
 
 func WithBasePath(basePath string) Option {
@@ -148,3 +150,35 @@ func WithSForce(force bool) Option {
 		return nil
 	}
 }
+
+func WithSStopTimeout(stopTimeout string) Option {
+	return func(s *Server) error {
+		s.SStopTimeout = stopTimeout
+		return nil
+	}
+}
+
+func WithSRuntime(runtime *Runtime) Option {
+	return func(s *Server) error {
+		if runtime != nil && runtime.Name == "" {
+			runtime = nil
+		}
+		if runtime != nil && !containsString(Runtimes, runtime.Name) {
+			return fmt.Errorf("invalid runtime: %s", runtime.Name)
+		}
+		s.SRuntime = runtime
+		return nil
+	}
+}
+
+func WithSOpenPorts(openPorts []string) Option {
+	return func(s *Server) error {
+		for _, openPort := range openPorts {
+			if _, err := ParseOpenPort(openPort); err != nil {
+				return err
+			}
+		}
+		s.SOpenPorts = openPorts
+		return nil
+	}
+}
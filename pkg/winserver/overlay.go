@@ -0,0 +1,174 @@
+package winserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ProfileNames 返回清单中定义的全部环境名
+func (m *Manifest) ProfileNames() []string {
+	names := make([]string, 0, len(m.Profiles))
+	for name := range m.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve 返回合并指定环境覆盖项后的服务定义，profile 为空时返回基础定义的副本
+func (m *Manifest) Resolve(name string, profile string) (*Server, error) {
+	base := m.Find(name)
+	if base == nil {
+		return nil, fmt.Errorf("service %s not found in manifest", name)
+	}
+	overlays, err := m.profile(profile)
+	if err != nil {
+		return nil, err
+	}
+	return MergeServer(base, serviceOverlay(overlays, base))
+}
+
+// ResolveAll 返回合并指定环境覆盖项后的全部服务定义
+func (m *Manifest) ResolveAll(profile string) ([]*Server, error) {
+	overlays, err := m.profile(profile)
+	if err != nil {
+		return nil, err
+	}
+	for name := range overlays {
+		if m.Find(name) == nil {
+			return nil, fmt.Errorf("profile %s: service %s not found in manifest", profile, name)
+		}
+	}
+	servers := make([]*Server, 0, len(m.Services))
+	for _, base := range m.Services {
+		s, err := MergeServer(base, serviceOverlay(overlays, base))
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, s)
+	}
+	return servers, nil
+}
+
+// serviceOverlay 按服务名或 ID 查找覆盖项
+func serviceOverlay(overlays map[string]map[string]any, base *Server) map[string]any {
+	if overlay, ok := overlays[base.SName]; ok {
+		return overlay
+	}
+	if base.SId != "" {
+		return overlays[base.SId]
+	}
+	return nil
+}
+
+func (m *Manifest) profile(profile string) (map[string]map[string]any, error) {
+	if profile == "" {
+		return nil, nil
+	}
+	overlays, ok := m.Profiles[profile]
+	if !ok {
+		return nil, fmt.Errorf("profile %s not found, available: %s", profile, strings.Join(m.ProfileNames(), ", "))
+	}
+	return overlays, nil
+}
+
+// MergeServer 将覆盖项深度合并到服务定义上并返回新的服务定义，不修改 base。
+// 对象按键递归合并，env 按变量名合并，其余值直接替换。
+func MergeServer(base *Server, overlay map[string]any) (*Server, error) {
+	merged, err := serverMap(base)
+	if err != nil {
+		return nil, err
+	}
+	if env, ok := overlay["env"]; ok {
+		overlay = copyMap(overlay)
+		overlay["env"] = mergeEnv(merged["env"], env)
+	}
+	deepMerge(merged, overlay)
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("服务定义编码失败: %v", err)
+	}
+	s := NewDefaultServer()
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(s); err != nil {
+		return nil, fmt.Errorf("service %s: invalid overlay: %v", base.SName, err)
+	}
+	s.BasePath = base.BasePath
+	s.sForce = base.sForce
+	return s, nil
+}
+
+// MergeDefaults 返回以 defaults 为基础合并 s 后的服务定义，s 中未设置的字段使用 defaults 中的值
+func MergeDefaults(defaults *Server, s *Server) (*Server, error) {
+	overlay, err := serverMap(s)
+	if err != nil {
+		return nil, err
+	}
+	merged, err := MergeServer(defaults, overlay)
+	if err != nil {
+		return nil, err
+	}
+	merged.BasePath = s.BasePath
+	merged.sForce = s.sForce
+	return merged, nil
+}
+
+// serverMap 将服务定义转换为 json 对象，未设置的字段不包含在内
+func serverMap(s *Server) (map[string]any, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("服务定义编码失败: %v", err)
+	}
+	m := map[string]any{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("服务定义解码失败: %v", err)
+	}
+	return m, nil
+}
+
+// deepMerge 将 src 递归合并到 dst
+func deepMerge(dst map[string]any, src map[string]any) {
+	for key, value := range src {
+		srcMap, srcOk := value.(map[string]any)
+		dstMap, dstOk := dst[key].(map[string]any)
+		if srcOk && dstOk {
+			deepMerge(dstMap, srcMap)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+func copyMap(m map[string]any) map[string]any {
+	c := make(map[string]any, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// mergeEnv 按变量名合并 KEY=VALUE 列表，覆盖项中的同名变量替换基础值
+func mergeEnv(base any, overlay any) []any {
+	var merged []any
+	index := map[string]int{}
+	add := func(items any) {
+		list, _ := items.([]any)
+		for _, item := range list {
+			key, _, _ := strings.Cut(fmt.Sprint(item), "=")
+			if i, ok := index[key]; ok {
+				merged[i] = item
+				continue
+			}
+			index[key] = len(merged)
+			merged = append(merged, item)
+		}
+	}
+	add(base)
+	add(overlay)
+	return merged
+}
@@ -0,0 +1,50 @@
+package winserver
+
+import "testing"
+
+func TestManifestResolveDefaultsAndAccount(t *testing.T) {
+	m := &Manifest{
+		Services: []*Server{
+			{SName: "web", SExecutable: "web.exe", SEnv: []string{"DEBUG=1", "PORT=80"}},
+			{SName: "api", SExecutable: "api.exe", SLogMode: "append"},
+		},
+		Profiles: map[string]map[string]map[string]any{
+			"prod": {
+				"web": {
+					"env":            []any{"DEBUG=0"},
+					"serviceaccount": map[string]any{"username": `.\svc-web`, "password": "secret"},
+				},
+			},
+		},
+	}
+	defaults := &Server{SLogPath: "logs", SLogMode: "roll-by-size"}
+	for i, base := range m.Services {
+		merged, err := MergeDefaults(defaults, base)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.Services[i] = merged
+	}
+
+	web, err := m.Resolve("web", "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if web.SLogPath != "logs" || web.SLogMode != "roll-by-size" {
+		t.Errorf("web log = %q %q, want the defaults", web.SLogPath, web.SLogMode)
+	}
+	if len(web.SEnv) != 2 || web.SEnv[0] != "DEBUG=0" || web.SEnv[1] != "PORT=80" {
+		t.Errorf("web env = %v", web.SEnv)
+	}
+	if web.SServiceAccount == nil || web.SServiceAccount.Username != `.\svc-web` || web.SServiceAccount.Password != "secret" {
+		t.Errorf("web service account = %+v", web.SServiceAccount)
+	}
+
+	api, err := m.Resolve("api", "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if api.SLogMode != "append" || api.SServiceAccount != nil {
+		t.Errorf("api = %+v, manifest values should win over defaults", api)
+	}
+}
@@ -0,0 +1,149 @@
+package winserver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gookit/goutil/fsutil"
+)
+
+// Runtimes 支持的语言运行时
+var Runtimes = []string{"python", "java", "node"}
+
+// Runtime 语言运行时配置。设置后 Server.SExecutable 表示应用入口
+// (python 脚本或 "-m module"、jar 文件或主类、node 脚本)，实际启动的是解释器。
+type Runtime struct {
+	// 语言运行时
+	Name string `json:"name" yaml:"name"`
+	// 解释器路径，为空时自动探测
+	Interpreter string `json:"interpreter,omitempty" yaml:"interpreter,omitempty"`
+	// python 虚拟环境目录，为空时在工作目录下查找 .venv 或 venv
+	Venv string `json:"venv,omitempty" yaml:"venv,omitempty"`
+	// JVM 参数
+	JvmOptions []string `json:"jvmoptions,omitempty" yaml:"jvmoptions,omitempty"`
+	// JVM 堆大小，例如 512m，同时设置 -Xms 和 -Xmx
+	Heap string `json:"heap,omitempty" yaml:"heap,omitempty"`
+}
+
+// 各运行时默认的停止超时时间，给应用留出优雅退出的时间
+var runtimeStopTimeouts = map[string]string{
+	"python": "15 sec",
+	"java":   "30 sec",
+	"node":   "10 sec",
+}
+
+// apply 根据运行时生成可执行文件、参数、环境变量和停止超时
+func (r *Runtime) apply(x *ServerXML, s *Server) error {
+	var executable string
+	var args []string
+	switch r.Name {
+	case "python":
+		executable = r.Interpreter
+		venv := r.Venv
+		if venv == "" {
+			venv = detectVenv(s.SWorkingDirectory)
+		}
+		if venv != "" {
+			venv = runtimePath(venv, s.SWorkingDirectory)
+			if executable == "" {
+				executable = venv + `\Scripts\python.exe`
+			}
+			addEnv(x, "VIRTUAL_ENV", venv)
+			addEnv(x, "PATH", venv+`\Scripts;%PATH%`)
+		}
+		if executable == "" {
+			executable = "python"
+		}
+		addEnv(x, "PYTHONUNBUFFERED", "1")
+		addEnv(x, "PYTHONIOENCODING", "utf-8")
+		args = append(args, entryArgs(s.SExecutable)...)
+	case "java":
+		executable = r.Interpreter
+		if executable == "" {
+			// 生成的配置不依赖当前机器的环境变量，JAVA_HOME 在服务运行时展开
+			executable = `%JAVA_HOME%\bin\java.exe`
+			if os.Getenv("JAVA_HOME") == "" {
+				fmt.Fprintf(os.Stderr, "warning: %s: JAVA_HOME is not set here, make sure it is set on the target or use --interpreter\n", s.SName)
+			}
+		}
+		if r.Heap != "" {
+			args = append(args, "-Xms"+r.Heap, "-Xmx"+r.Heap)
+		}
+		args = append(args, r.JvmOptions...)
+		entry := entryArgs(s.SExecutable)
+		if len(entry) > 0 && strings.EqualFold(filepath.Ext(entry[0]), ".jar") {
+			args = append(args, "-jar")
+		}
+		args = append(args, entry...)
+	case "node":
+		executable = r.Interpreter
+		if executable == "" {
+			executable = "node"
+		}
+		addEnv(x, "NODE_ENV", "production")
+		args = append(args, entryArgs(s.SExecutable)...)
+	}
+
+	x.Executable = executable
+	prefix := JoinArgs(args)
+	if x.StartArguments != "" {
+		x.StartArguments = strings.TrimSpace(prefix + " " + x.StartArguments)
+	} else {
+		x.Arguments = strings.TrimSpace(prefix + " " + x.Arguments)
+	}
+	if x.StopTimeout == "" {
+		x.StopTimeout = runtimeStopTimeouts[r.Name]
+	}
+	return nil
+}
+
+// entryScriptExts 入口为这些扩展名的文件时，整个入口视为一个路径，路径中可以包含空格
+var entryScriptExts = []string{".py", ".pyw", ".jar", ".js", ".mjs", ".cjs"}
+
+// entryArgs 拆分应用入口，例如 "-m mypkg.app" 或 "-cp lib\* com.example.Main"，
+// 未加引号但以脚本扩展名结尾的入口 (如 C:\my app\main.py) 作为一个参数
+func entryArgs(entry string) []string {
+	entry = strings.TrimSpace(entry)
+	if !strings.ContainsAny(entry, `"'`) && !strings.HasPrefix(entry, "-") &&
+		containsString(entryScriptExts, strings.ToLower(filepath.Ext(entry))) {
+		return []string{entry}
+	}
+	return SplitArgs(entry)
+}
+
+// detectVenv 在工作目录下查找 python 虚拟环境
+func detectVenv(dir string) string {
+	if dir == "" {
+		dir = "."
+	}
+	for _, name := range []string{".venv", "venv"} {
+		if fsutil.FileExist(filepath.Join(dir, name, "pyvenv.cfg")) {
+			return name
+		}
+	}
+	return ""
+}
+
+// runtimePath 将相对路径转换为服务运行时的路径，相对于工作目录或服务所在目录 (%BASE%)
+func runtimePath(p string, workingDirectory string) string {
+	if filepath.IsAbs(p) || strings.HasPrefix(p, "%") || strings.Contains(p, ":") {
+		return p
+	}
+	base := workingDirectory
+	if base == "" {
+		base = "%BASE%"
+	}
+	return base + `\` + p
+}
+
+// addEnv 添加环境变量，已存在的同名变量保持不变
+func addEnv(x *ServerXML, name string, value string) {
+	for _, e := range x.Env {
+		if e.Name == name {
+			return
+		}
+	}
+	x.Env = append(x.Env, &Env{Name: name, Value: value})
+}
@@ -0,0 +1,60 @@
+package winserver
+
+import "testing"
+
+func TestRuntimeEntryArguments(t *testing.T) {
+	t.Setenv("JAVA_HOME", `C:\jdk`)
+	tests := []struct {
+		runtime    *Runtime
+		entry      string
+		arguments  string
+		executable string
+		want       string
+	}{
+		{&Runtime{Name: "python"}, "-m mypkg.app", "--port 8000", "python", "-m mypkg.app --port 8000"},
+		{&Runtime{Name: "python"}, `C:\my app\main.py`, "", "python", `"C:\my app\main.py"`},
+		{&Runtime{Name: "python"}, `"C:\my app\main.py" --debug`, "", "python", `"C:\my app\main.py" --debug`},
+		{&Runtime{Name: "java", Heap: "512m"}, "app.jar", "--server.port=8080", `%JAVA_HOME%\bin\java.exe`, "-Xms512m -Xmx512m -jar app.jar --server.port=8080"},
+		{&Runtime{Name: "java"}, `lib\my app.jar`, "", `%JAVA_HOME%\bin\java.exe`, `-jar "lib\my app.jar"`},
+		{&Runtime{Name: "java"}, `-cp lib\* com.example.Main`, "", `%JAVA_HOME%\bin\java.exe`, `-cp lib\* com.example.Main`},
+		{&Runtime{Name: "node", Interpreter: `C:\node\node.exe`}, `D:\my site\server.js`, "", `C:\node\node.exe`, `"D:\my site\server.js"`},
+		{&Runtime{Name: "node"}, "--enable-source-maps dist/index.js", "", "node", "--enable-source-maps dist/index.js"},
+	}
+	for _, tt := range tests {
+		s := &Server{SName: "app", SExecutable: tt.entry, SArguments: tt.arguments, SLogMode: "none", SRuntime: tt.runtime}
+		serverXML, err := s.BuildServerXML()
+		if err != nil {
+			t.Fatalf("%s %q: %v", tt.runtime.Name, tt.entry, err)
+		}
+		if serverXML.Executable != tt.executable {
+			t.Errorf("%s %q: executable = %q, want %q", tt.runtime.Name, tt.entry, serverXML.Executable, tt.executable)
+		}
+		if serverXML.Arguments != tt.want {
+			t.Errorf("%s %q: arguments = %q, want %q", tt.runtime.Name, tt.entry, serverXML.Arguments, tt.want)
+		}
+	}
+}
+
+func TestRuntimeStartArgumentsAndDefaults(t *testing.T) {
+	s := &Server{
+		SName:           "app",
+		SExecutable:     "-m mypkg.app",
+		SStartArguments: "serve",
+		SStopExecutable: "python",
+		SLogMode:        "none",
+		SRuntime:        &Runtime{Name: "python", Venv: "env"},
+	}
+	serverXML, err := s.BuildServerXML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if serverXML.Executable != `%BASE%\env\Scripts\python.exe` {
+		t.Errorf("executable = %q", serverXML.Executable)
+	}
+	if serverXML.StartArguments != "-m mypkg.app serve" || serverXML.Arguments != "" {
+		t.Errorf("startarguments = %q, arguments = %q", serverXML.StartArguments, serverXML.Arguments)
+	}
+	if serverXML.StopTimeout != "15 sec" {
+		t.Errorf("stoptimeout = %q, want the python default", serverXML.StopTimeout)
+	}
+}
@@ -0,0 +1,158 @@
+package winserver
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+//go:generate go run ./internal/schemadoc/gen Server ServerXML
+
+const schemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// Schema 是 JSON Schema (draft-07) 的一个子集
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+}
+
+// schemaEnums 字段枚举值，键为 "类型名.字段名"
+var schemaEnums = map[string][]string{
+	"Server.SStartMode":   StartModes,
+	"Server.SLogMode":     LogModes,
+	"ServerXML.StartMode": StartModes,
+	"Log.Mode":            LogModes,
+	"OnFailure.Action":    FailureActions,
+	"Runtime.Name":        Runtimes,
+}
+
+// schemaPatterns 字段正则约束，键为 "类型名.字段名"
+var schemaPatterns = map[string]string{
+	"Server.SFailure":   failurePattern(),
+	"Server.SEnv":       `^[^=]+=`,
+	"Server.SOpenPorts": `^([tT][cC][pP]|[uU][dD][pP]):\d+(-\d+)?$`,
+}
+
+// schemaRequired 必填字段，键为类型名
+var schemaRequired = map[string][]string{
+	"Server":    {"name", "executable"},
+	"ServerXML": {"id", "executable"},
+	"Runtime":   {"name"},
+}
+
+func failurePattern() string {
+	action := "(" + strings.Join(FailureActions, "|") + ")(:[^,]+)?"
+	return "^" + action + "(," + action + ")*$"
+}
+
+// ServerSchema 生成服务定义 (Server) 的 JSON Schema
+func ServerSchema() (*Schema, error) {
+	return rootSchema(reflect.TypeOf(Server{}), "win_helper service definition")
+}
+
+// ServerXMLSchema 生成 WinSW 配置 (ServerXML) 的 JSON Schema
+func ServerXMLSchema() (*Schema, error) {
+	return rootSchema(reflect.TypeOf(ServerXML{}), "WinSW service configuration")
+}
+
+func rootSchema(t reflect.Type, title string) (*Schema, error) {
+	schema, err := typeSchema(t, "")
+	if err != nil {
+		return nil, err
+	}
+	schema.Schema = schemaDraft
+	schema.Title = title
+	return schema, nil
+}
+
+// typeSchema 通过反射生成类型的 Schema，key 为字段的 "类型名.字段名"。
+// 字段说明来自 go generate 根据字段注释生成的 schemaDocs。
+func typeSchema(t reflect.Type, key string) (*Schema, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	schema := &Schema{Description: schemaDocs[key]}
+	if key != "" {
+		if pattern, ok := schemaPatterns[key]; ok {
+			schema.Pattern = pattern
+		}
+		if enum, ok := schemaEnums[key]; ok {
+			schema.Enum = enum
+		}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		schema.Type = "string"
+	case reflect.Bool:
+		schema.Type = "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		schema.Type = "integer"
+	case reflect.Slice, reflect.Array:
+		items, err := typeSchema(t.Elem(), "")
+		if err != nil {
+			return nil, err
+		}
+		items.Pattern, schema.Pattern = schema.Pattern, ""
+		items.Enum, schema.Enum = schema.Enum, nil
+		schema.Type = "array"
+		schema.Items = items
+	case reflect.Struct:
+		additional := false
+		schema.Type = "object"
+		schema.Properties = map[string]*Schema{}
+		schema.Required = schemaRequired[t.Name()]
+		schema.AdditionalProperties = &additional
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := jsonFieldName(field)
+			if name == "" {
+				continue
+			}
+			fieldKey := t.Name() + "." + field.Name
+			property, err := typeSchema(field.Type, fieldKey)
+			if err != nil {
+				return nil, err
+			}
+			schema.Properties[name] = property
+		}
+	default:
+		return nil, fmt.Errorf("unsupported schema kind %s", t.Kind())
+	}
+	return schema, nil
+}
+
+// jsonFieldName 返回字段的 json 名称，忽略的字段返回空字符串
+func jsonFieldName(field reflect.StructField) string {
+	if !field.IsExported() || field.Type == reflect.TypeOf(xml.Name{}) {
+		return ""
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// ToJson 输出格式化后的 Schema
+func (s *Schema) ToJson() (string, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("schema 编码失败: %v", err)
+	}
+	return string(data), nil
+}
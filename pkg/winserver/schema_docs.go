@@ -0,0 +1,77 @@
+// Code generated by go run ./internal/schemadoc/gen Server ServerXML; DO NOT EDIT.
+
+package winserver
+
+// schemaDocs 字段说明，键为 "类型名.字段名"，由字段注释生成
+var schemaDocs = map[string]string{
+	"AdditionalCommands.Arguments":     "命令的参数",
+	"AdditionalCommands.Executable":    "命令的可执行文件",
+	"AdditionalCommands.StderrPath":    "stderrPath specifies the path to redirect the standard error output to.\nSpecify in or to dispose of the corresponding stream.NULstdoutPathstderrPath",
+	"AdditionalCommands.StdoutPath":    "stdoutPath specifies the path to redirect the standard output to.",
+	"Dependency.Value":                 "依赖的服务 id",
+	"Env.Name":                         "环境变量名",
+	"Env.Value":                        "环境变量值",
+	"Log.AutoRollAtTime":               "每天滚动日志的时间",
+	"Log.KeepFiles":                    "保留的日志文件数",
+	"Log.Mode":                         "日志模式",
+	"Log.Pattern":                      "roll-by-time 使用的日志文件名格式",
+	"Log.SizeThreshold":                "日志滚动的大小阈值，单位 KB",
+	"Log.ZipDateFormat":                "压缩文件名的日期格式",
+	"Log.ZipOlderThanNumDays":          "压缩超过指定天数的日志文件",
+	"OnFailure.Action":                 "服务失败时执行的动作",
+	"OnFailure.Delay":                  "执行动作前的等待时间，例如 10 sec",
+	"Runtime.Heap":                     "JVM 堆大小，例如 512m，同时设置 -Xms 和 -Xmx",
+	"Runtime.Interpreter":              "解释器路径，为空时自动探测",
+	"Runtime.JvmOptions":               "JVM 参数",
+	"Runtime.Name":                     "语言运行时",
+	"Runtime.Venv":                     "python 虚拟环境目录，为空时在工作目录下查找 .venv 或 venv",
+	"Server.SArguments":                "传给可执行文件的参数",
+	"Server.SDepends":                  "依赖的其他服务的 id",
+	"Server.SDescription":              "服务描述，显示在 Windows 服务管理器中",
+	"Server.SEnv":                      "环境变量，格式为 KEY=VALUE",
+	"Server.SExecutable":               "必填，要启动的可执行文件，可以是绝对路径或在 PATH 中查找的文件名",
+	"Server.SFailure":                  "失败策略，格式为 action[:delay]，多个动作以逗号分隔，例如 restart:10 sec,reboot",
+	"Server.SId":                       "服务的唯一标识，为空时使用 name",
+	"Server.SLogAutoRollAtTime":        "roll-by-size-time 每天滚动日志的时间",
+	"Server.SLogKeepFiles":             "保留的日志文件数",
+	"Server.SLogMode":                  "日志模式",
+	"Server.SLogPath":                  "服务日志目录",
+	"Server.SLogPattern":               "roll-by-time 使用的日志文件名格式",
+	"Server.SLogSizeThreshold":         "日志滚动的大小阈值，单位 KB",
+	"Server.SName":                     "服务的显示名称，在系统中唯一",
+	"Server.SOpenPorts":                "入站防火墙端口，例如 tcp:9000 或 udp:8000-8010",
+	"Server.SRuntime":                  "语言运行时，设置后 executable 为应用入口 (脚本、模块或 jar)",
+	"Server.SServiceAccount":           "运行服务的账户，为空时使用 LocalSystem",
+	"Server.SStartArguments":           "启动时传给可执行文件的参数，与 stopexecutable 一起使用",
+	"Server.SStartMode":                "服务的启动模式，默认为 Automatic",
+	"Server.SStopArguments":            "传给 stopexecutable 的参数",
+	"Server.SStopExecutable":           "停止服务时启动的可执行文件，用于优雅关闭服务进程",
+	"Server.SStopTimeout":              "等待服务优雅退出的时间，例如 15 sec",
+	"Server.SWorkingDirectory":         "可执行文件的工作目录",
+	"ServerXML.Arguments":              "Optional The element specifies the arguments to be passed to the executable.<arguments>\n<arguments>arg1 arg2 arg3</arguments>\n-or-\n<arguments>\narg1\narg2\narg3\n</arguments>",
+	"ServerXML.BeepOnShutdown":         "哔哔关门\n可选元素用于在服务关闭时发出简单的提示音。 此功能应仅用于调试，因为某些操作系统和硬件不支持此功能。",
+	"ServerXML.Dependencies":           "Optional Specify IDs of other services that this service depends on. When service X depends on service Y, X can only run if Y is running.\nMultiple elements can be used to specify multiple dependencies.",
+	"ServerXML.Description":            "Optional Long human-readable description of the service. This gets displayed in Windows service manager when the service is selected.",
+	"ServerXML.Env":                    "环境",
+	"ServerXML.Executable":             "Required This element specifies the executable to be launched. It can be either absolute path, or you can just specify the executable name and let it be searched from PATH (although note that the services often run in a different user account and therefore it might have different PATH than your shell does.)",
+	"ServerXML.Id":                     "服务的唯一标识",
+	"ServerXML.Log":                    "日志设置",
+	"ServerXML.LogPath":                "服务日志目录",
+	"ServerXML.Name":                   "Optional Short display name of the service, which can contain spaces and other characters. This shouldn't be too long, like <id>, and this also needs to be unique among all the services in a given system.",
+	"ServerXML.OnFailures":             "OnFailures（失败）\n服务失败时执行的动作",
+	"ServerXML.PostStart":              "服务启动后执行的命令",
+	"ServerXML.PreShutdown":            "关机前\n在系统关闭时为服务提供更多停止时间。",
+	"ServerXML.PreShutdownTimeout":     "系统默认的关机前超时时间为三分钟。",
+	"ServerXML.PreStart":               "服务启动前执行的命令",
+	"ServerXML.PreStop":                "服务停止前执行的命令",
+	"ServerXML.ServiceAccount":         "运行服务的账户，为空时使用 LocalSystem",
+	"ServerXML.StartArguments":         "stopargument/stopexecutable\nOptional When the service is requested to stop, winsw simply calls TerminateProcess function to kill the service instantly.\nHowever, if the element is present, winsw will instead launch another process of (or if that's specified) with the\nspecified arguments, and expects that to initiate the graceful shutdown of the service process.<stoparguments><executable><stopexecutable>\nWinsw will then wait for the two processes to exit on its own, before reporting back to Windows that the service has terminated.\nWhen you use the , you must use instead of . See the complete example below:<stoparguments><startarguments><arguments>\n<executable>catalina.sh</executable>\n<startarguments>jpda run</startarguments>\n<stopexecutable>catalina.sh</stopexecutable>\n<stoparguments>stop</stoparguments>",
+	"ServerXML.StartMode":              "Optional This element specifies the start mode of the Windows service. It can be one of the following values: Automatic, or Manual. For more information, see the ChangeStartMode method. The default value is Automatic.\nBoot Start (\"Boot\")\nDevice driver started by the operating system loader. This value is valid only for driver services.\nSystem (\"System\")\nDevice driver started by the operating system initialization process. This value is valid only for driver services.\nAuto Start (\"Automatic\")\nService to be started automatically by the service control manager during system startup.\nDemand Start (\"Manual\")\nService to be started by the service control manager when a process calls the StartService method.\nDisabled (\"Disabled\")\nService that can no longer be started.",
+	"ServerXML.StopArguments":          "传给 stopexecutable 的参数",
+	"ServerXML.StopExecutable":         "停止服务时启动的可执行文件",
+	"ServerXML.StopTimeout":            "等待服务优雅退出的时间",
+	"ServerXML.WorkingDirectory":       "可执行文件的工作目录",
+	"ServiceAccount.AllowServiceLogon": "为账户授予作为服务登录的权限",
+	"ServiceAccount.Password":          "账户密码，内置账户不需要密码",
+	"ServiceAccount.Username":          "账户名，格式为 DOMAIN\\user 或 .\\user，也可以是 LocalService、NetworkService",
+}
@@ -0,0 +1,96 @@
+package winserver
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"win_helper/pkg/winserver/internal/schemadoc"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+// TestSchemaDocsGenerated 字段注释修改后需要重新执行 go generate
+func TestSchemaDocsGenerated(t *testing.T) {
+	want, err := schemadoc.Generate(".", "winserver", "Server", "ServerXML")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(schemadoc.OutputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("%s is out of date, run go generate ./pkg/winserver", schemadoc.OutputFile)
+	}
+}
+
+// TestSchemaDescriptions 每个字段都需要注释作为说明
+func TestSchemaDescriptions(t *testing.T) {
+	for _, fn := range []func() (*Schema, error){ServerSchema, ServerXMLSchema} {
+		schema, err := fn()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var missing []string
+		walkSchema(schema, schema.Title, func(path string, s *Schema) {
+			if s.Description == "" {
+				missing = append(missing, path)
+			}
+		})
+		sort.Strings(missing)
+		if len(missing) > 0 {
+			t.Errorf("fields without a comment: %v", missing)
+		}
+	}
+}
+
+func walkSchema(s *Schema, path string, fn func(path string, s *Schema)) {
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		property := s.Properties[name]
+		fn(path+"."+name, property)
+		walkSchema(property, path+"."+name, fn)
+		if property.Items != nil {
+			walkSchema(property.Items, path+"."+name+"[]", fn)
+		}
+	}
+}
+
+func TestSchemaGolden(t *testing.T) {
+	for name, fn := range map[string]func() (*Schema, error){
+		"server.schema.json": ServerSchema,
+		"xml.schema.json":    ServerXMLSchema,
+	} {
+		t.Run(name, func(t *testing.T) {
+			schema, err := fn()
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := schema.ToJson()
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", name)
+			if *update {
+				if err := os.WriteFile(golden, []byte(out+"\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(want) != out+"\n" {
+				t.Fatalf("schema differs from %s, run go test ./pkg/winserver -run TestSchemaGolden -update", golden)
+			}
+		})
+	}
+}
@@ -14,7 +14,8 @@ import (
 
 type ServerXML struct {
 	XMLName xml.Name `xml:"service"`
-	Id      string   `xml:"id" json:"id"`
+	// 服务的唯一标识
+	Id string `xml:"id" json:"id"`
 	// Executable
 	// Required This element specifies the executable to be launched. It can be either absolute path, or you can just specify the executable name and let it be searched from PATH (although note that the services often run in a different user account and therefore it might have different PATH than your shell does.)
 	Executable string `xml:"executable" json:"executable"`
@@ -40,7 +41,8 @@ type ServerXML struct {
 	// Optional Specify IDs of other services that this service depends on. When service X depends on service Y, X can only run if Y is running.
 	// Multiple elements can be used to specify multiple dependencies.
 	Dependencies []*Dependency `xml:"depend,omitempty" json:"dependencies,omitempty"`
-	LogPath      string        `xml:"logpath,omitempty" json:"logpath,omitempty"`
+	// 服务日志目录
+	LogPath string `xml:"logpath,omitempty" json:"logpath,omitempty"`
 	//Arguments
 	//Optional The element specifies the arguments to be passed to the executable.<arguments>
 	//<arguments>arg1 arg2 arg3</arguments>
@@ -63,12 +65,18 @@ type ServerXML struct {
 	//<stopexecutable>catalina.sh</stopexecutable>
 	//<stoparguments>stop</stoparguments>
 	StartArguments string `xml:"startarguments,omitempty" json:"startarguments,omitempty"`
+	// 停止服务时启动的可执行文件
 	StopExecutable string `xml:"stopexecutable,omitempty" json:"stopexecutable,omitempty"`
-	StopArguments  string `xml:"stoparguments,omitempty" json:"stoparguments,omitempty"`
+	// 传给 stopexecutable 的参数
+	StopArguments string `xml:"stoparguments,omitempty" json:"stoparguments,omitempty"`
 	// Additional commands
-	PreStart  *AdditionalCommands `xml:"prestart,omitempty" json:"prestart,omitempty"`
+
+	// 服务启动前执行的命令
+	PreStart *AdditionalCommands `xml:"prestart,omitempty" json:"prestart,omitempty"`
+	// 服务启动后执行的命令
 	PostStart *AdditionalCommands `xml:"poststart,omitempty" json:"poststart,omitempty"`
-	PreStop   *AdditionalCommands `xml:"prestop,omitempty" json:"prestop,omitempty"`
+	// 服务停止前执行的命令
+	PreStop *AdditionalCommands `xml:"prestop,omitempty" json:"prestop,omitempty"`
 
 	// 关机前
 	// 在系统关闭时为服务提供更多停止时间。
@@ -77,22 +85,30 @@ type ServerXML struct {
 	PreShutdownTimeout string `xml:"preshutdownTimeout,omitempty" json:"preshutdownTimeout,omitempty"`
 
 	// StopTimeout
+	// 等待服务优雅退出的时间
 	StopTimeout string `xml:"stoptimeout,omitempty" json:"stoptimeout,omitempty"`
 	// 环境
 	Env []*Env `xml:"env,omitempty" json:"env,omitempty"`
+	// 运行服务的账户，为空时使用 LocalSystem
+	ServiceAccount *ServiceAccount `xml:"serviceaccount,omitempty" json:"serviceaccount,omitempty"`
 	// 哔哔关门
 	// 可选元素用于在服务关闭时发出简单的提示音。 此功能应仅用于调试，因为某些操作系统和硬件不支持此功能。
 	BeepOnShutdown bool `xml:"beeponshutdown,omitempty" json:"beeponshutdown,omitempty"`
-	Log            *Log `xml:"log" json:"log"`
+	// 日志设置
+	Log *Log `xml:"log" json:"log"`
 	// OnFailures（失败）
+	// 服务失败时执行的动作
 	OnFailures []*OnFailure `xml:"onfailure,omitempty" json:"onfailures,omitempty"`
 
+	// 可执行文件的工作目录
 	WorkingDirectory string `xml:"workingdirectory,omitempty" json:"workingdirectory,omitempty"`
 }
 
 type AdditionalCommands struct {
+	// 命令的可执行文件
 	Executable string `xml:"executable,omitempty" json:"executable,omitempty"`
-	Arguments  string `xml:"arguments,omitempty" json:"arguments,omitempty"`
+	// 命令的参数
+	Arguments string `xml:"arguments,omitempty" json:"arguments,omitempty"`
 	// stdoutPath specifies the path to redirect the standard output to.
 	StdoutPath string `xml:"stdoutPath,omitempty" json:"stdoutPath,omitempty"`
 	// stderrPath specifies the path to redirect the standard error output to.
@@ -101,27 +117,84 @@ type AdditionalCommands struct {
 }
 
 type OnFailure struct {
-	Action string `xml:"action,omitempty" json:"action,omitempty"`
-	Delay  string `xml:"delay,omitempty" json:"delay,omitempty"`
+	// 服务失败时执行的动作
+	Action string `xml:"action,attr,omitempty" json:"action,omitempty"`
+	// 执行动作前的等待时间，例如 10 sec
+	Delay string `xml:"delay,attr,omitempty" json:"delay,omitempty"`
 }
 type Env struct {
-	Name  string `xml:"name,attr" json:"name"`
+	// 环境变量名
+	Name string `xml:"name,attr" json:"name"`
+	// 环境变量值
 	Value string `xml:"value,attr" json:"value"`
 }
 
 type Log struct {
-	Mode                string `xml:"mode,attr" json:"mode"`
-	Pattern             string `xml:"pattern,omitempty" json:"pattern,omitempty"`
-	AutoRollAtTime      string `xml:"autoRollAtTime,omitempty" json:"autoRollAtTime,omitempty"`
-	SizeThreshold       int    `xml:"sizeThreshold,omitempty" json:"sizeThreshold,omitempty"`
-	KeepFiles           int    `xml:"keepFiles,omitempty" json:"keepFiles,omitempty"`
+	// 日志模式
+	Mode string `xml:"mode,attr" json:"mode"`
+	// roll-by-time 使用的日志文件名格式
+	Pattern string `xml:"pattern,omitempty" json:"pattern,omitempty"`
+	// 每天滚动日志的时间
+	AutoRollAtTime string `xml:"autoRollAtTime,omitempty" json:"autoRollAtTime,omitempty"`
+	// 日志滚动的大小阈值，单位 KB
+	SizeThreshold int `xml:"sizeThreshold,omitempty" json:"sizeThreshold,omitempty"`
+	// 保留的日志文件数
+	KeepFiles int `xml:"keepFiles,omitempty" json:"keepFiles,omitempty"`
+	// 压缩超过指定天数的日志文件
 	ZipOlderThanNumDays string `xml:"zipOlderThanNumDays,omitempty" json:"zipOlderThanNumDays,omitempty"`
-	ZipDateFormat       string `xml:"zipDateFormat,omitempty" json:"zipDateFormat,omitempty"`
+	// 压缩文件名的日期格式
+	ZipDateFormat string `xml:"zipDateFormat,omitempty" json:"zipDateFormat,omitempty"`
 }
 
 type Dependency struct {
 	XMLName xml.Name `xml:"depend,omitempty" json:"-"`
-	Value   string   `xml:",chardata" json:"value"`
+	// 依赖的服务 id
+	Value string `xml:",chardata" json:"value"`
+}
+
+type ServiceAccount struct {
+	// 账户名，格式为 DOMAIN\user 或 .\user，也可以是 LocalService、NetworkService
+	Username string `xml:"username" json:"username" yaml:"username"`
+	// 账户密码，内置账户不需要密码
+	Password string `xml:"password,omitempty" json:"password,omitempty" yaml:"password,omitempty"`
+	// 为账户授予作为服务登录的权限
+	AllowServiceLogon bool `xml:"allowservicelogon,omitempty" json:"allowservicelogon,omitempty" yaml:"allowservicelogon,omitempty"`
+}
+
+var (
+	// StartModes 服务启动模式
+	StartModes = []string{"Boot", "System", "Automatic", "Manual", "Disabled"}
+	// LogModes WinSW 支持的日志模式
+	LogModes = []string{"append", "reset", "none", "roll", "roll-by-size", "roll-by-time", "roll-by-size-time"}
+	// FailureActions 服务失败时可执行的动作
+	FailureActions = []string{"restart", "reboot", "none"}
+)
+
+// ParseFailure 解析失败策略，格式为 action[:delay]，多个动作以逗号分隔，例如 "restart:10 sec,reboot"
+func ParseFailure(failure string) ([]*OnFailure, error) {
+	var onFailures []*OnFailure
+	for _, item := range strings.Split(failure, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		action, delay, _ := strings.Cut(item, ":")
+		action = strings.TrimSpace(action)
+		if !containsString(FailureActions, action) {
+			return nil, fmt.Errorf("invalid failure action: %s", action)
+		}
+		onFailures = append(onFailures, &OnFailure{Action: action, Delay: strings.TrimSpace(delay)})
+	}
+	return onFailures, nil
+}
+
+func containsString(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
 }
 
 func (s *ServerXML) ToJson() string {
@@ -159,29 +232,56 @@ func (s *ServerXML) LoadXML(data string) (*ServerXML, error) {
 }
 
 type Server struct {
-	BasePath string
+	BasePath string `json:"-" yaml:"-"`
 	sForce   bool
 
-	SId               string
-	SExecutable       string
-	SName             string
-	SDescription      string
-	SStartMode        string
-	SDepends          []string
-	SLogPath          string
-	SArguments        string
-	SStartArguments   string
-	SStopExecutable   string
-	SStopArguments    string
-	SEnv              []string
-	SFailure          string
-	SWorkingDirectory string
-
-	SLogMode           string
-	SLogPattern        string
-	SLogAutoRollAtTime string
-	SLogSizeThreshold  int
-	SLogKeepFiles      int
+	// 服务的唯一标识，为空时使用 name
+	SId string `json:"id,omitempty" yaml:"id,omitempty"`
+	// 必填，要启动的可执行文件，可以是绝对路径或在 PATH 中查找的文件名
+	SExecutable string `json:"executable" yaml:"executable"`
+	// 服务的显示名称，在系统中唯一
+	SName string `json:"name" yaml:"name"`
+	// 服务描述，显示在 Windows 服务管理器中
+	SDescription string `json:"description,omitempty" yaml:"description,omitempty"`
+	// 服务的启动模式，默认为 Automatic
+	SStartMode string `json:"startmode,omitempty" yaml:"startmode,omitempty"`
+	// 依赖的其他服务的 id
+	SDepends []string `json:"depends,omitempty" yaml:"depends,omitempty"`
+	// 服务日志目录
+	SLogPath string `json:"logpath,omitempty" yaml:"logpath,omitempty"`
+	// 传给可执行文件的参数
+	SArguments string `json:"arguments,omitempty" yaml:"arguments,omitempty"`
+	// 启动时传给可执行文件的参数，与 stopexecutable 一起使用
+	SStartArguments string `json:"startarguments,omitempty" yaml:"startarguments,omitempty"`
+	// 停止服务时启动的可执行文件，用于优雅关闭服务进程
+	SStopExecutable string `json:"stopexecutable,omitempty" yaml:"stopexecutable,omitempty"`
+	// 传给 stopexecutable 的参数
+	SStopArguments string `json:"stoparguments,omitempty" yaml:"stoparguments,omitempty"`
+	// 环境变量，格式为 KEY=VALUE
+	SEnv []string `json:"env,omitempty" yaml:"env,omitempty"`
+	// 失败策略，格式为 action[:delay]，多个动作以逗号分隔，例如 restart:10 sec,reboot
+	SFailure string `json:"failure,omitempty" yaml:"failure,omitempty"`
+	// 可执行文件的工作目录
+	SWorkingDirectory string `json:"workingdirectory,omitempty" yaml:"workingdirectory,omitempty"`
+	// 等待服务优雅退出的时间，例如 15 sec
+	SStopTimeout string `json:"stoptimeout,omitempty" yaml:"stoptimeout,omitempty"`
+	// 语言运行时，设置后 executable 为应用入口 (脚本、模块或 jar)
+	SRuntime *Runtime `json:"runtime,omitempty" yaml:"runtime,omitempty"`
+	// 入站防火墙端口，例如 tcp:9000 或 udp:8000-8010
+	SOpenPorts []string `json:"openports,omitempty" yaml:"openports,omitempty"`
+	// 运行服务的账户，为空时使用 LocalSystem
+	SServiceAccount *ServiceAccount `json:"serviceaccount,omitempty" yaml:"serviceaccount,omitempty"`
+
+	// 日志模式
+	SLogMode string `json:"logmode,omitempty" yaml:"logmode,omitempty"`
+	// roll-by-time 使用的日志文件名格式
+	SLogPattern string `json:"logpattern,omitempty" yaml:"logpattern,omitempty"`
+	// roll-by-size-time 每天滚动日志的时间
+	SLogAutoRollAtTime string `json:"logautorollattime,omitempty" yaml:"logautorollattime,omitempty"`
+	// 日志滚动的大小阈值，单位 KB
+	SLogSizeThreshold int `json:"logsizethreshold,omitempty" yaml:"logsizethreshold,omitempty"`
+	// 保留的日志文件数
+	SLogKeepFiles int `json:"logkeepfiles,omitempty" yaml:"logkeepfiles,omitempty"`
 }
 
 func NewDefaultServer() *Server {
@@ -192,13 +292,21 @@ func NewDefaultServer() *Server {
 
 func NewServer(opts ...Option) (*Server, error) {
 	s := NewDefaultServer()
+	if err := s.Apply(opts...); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Apply 将选项应用到已有的服务定义，例如从清单中读取的服务
+func (s *Server) Apply(opts ...Option) error {
 	for _, opt := range opts {
 		err := opt(s)
 		if err != nil {
-			return nil, err
+			return err
 		}
 	}
-	return s, nil
+	return nil
 }
 
 func (s *Server) GenerateServer() error {
@@ -220,12 +328,22 @@ func (s *Server) GenerateServer() error {
 	return nil
 }
 
-func (s *Server) GenerateServerXML() error {
+// BuildServerXML 根据服务定义构造 WinSW 配置
+func (s *Server) BuildServerXML() (*ServerXML, error) {
+	// 与 schema 中的 startmode 枚举保持一致
+	if s.SStartMode != "" && !containsString(StartModes, s.SStartMode) {
+		return nil, fmt.Errorf("invalid start mode: %s", s.SStartMode)
+	}
+	id := s.SId
+	if id == "" {
+		id = s.SName
+	}
 	serverXML := &ServerXML{
-		Id:          s.SName,
+		Id:          id,
 		Name:        s.SName,
 		Description: s.SDescription,
 		Executable:  s.SExecutable,
+		StartMode:   s.SStartMode,
 		Log: &Log{
 			Mode: s.SLogMode,
 		},
@@ -284,6 +402,31 @@ func (s *Server) GenerateServerXML() error {
 		}
 	}
 
+	serverXML.WorkingDirectory = s.SWorkingDirectory
+	if s.SFailure != "" {
+		onFailures, err := ParseFailure(s.SFailure)
+		if err != nil {
+			return nil, err
+		}
+		serverXML.OnFailures = onFailures
+	}
+
+	serverXML.StopTimeout = s.SStopTimeout
+	serverXML.ServiceAccount = s.SServiceAccount
+	if s.SRuntime != nil {
+		if err := s.SRuntime.apply(serverXML, s); err != nil {
+			return nil, err
+		}
+	}
+	return serverXML, nil
+}
+
+func (s *Server) GenerateServerXML() error {
+	serverXML, err := s.BuildServerXML()
+	if err != nil {
+		return err
+	}
+
 	// 输出调试信息（可选）
 	fmt.Println(serverXML.ToJson())
 
@@ -328,5 +471,9 @@ func (s *Server) Generate() error {
 	if err != nil {
 		return err
 	}
+	err = s.GenerateFirewallScripts()
+	if err != nil {
+		return err
+	}
 	return nil
 }
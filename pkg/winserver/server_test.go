@@ -0,0 +1,76 @@
+package winserver
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestBuildServerXMLServiceFields(t *testing.T) {
+	s := &Server{
+		SId:               "api-svc",
+		SName:             "api",
+		SExecutable:       "api.exe",
+		SStartMode:        "Manual",
+		SWorkingDirectory: "bin",
+		SFailure:          "restart:10 sec,reboot",
+		SLogMode:          "none",
+	}
+	serverXML, err := s.BuildServerXML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := serverXML.ToXML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []string{
+		`<id>api-svc</id>`,
+		`<startmode>Manual</startmode>`,
+		`<workingdirectory>bin</workingdirectory>`,
+		`<onfailure action="restart" delay="10 sec"></onfailure>`,
+		`<onfailure action="reboot"></onfailure>`,
+	} {
+		if !strings.Contains(out, w) {
+			t.Errorf("xml missing %s:\n%s", w, out)
+		}
+	}
+
+	s.SId = ""
+	if serverXML, err = s.BuildServerXML(); err != nil {
+		t.Fatal(err)
+	}
+	if serverXML.Id != "api" {
+		t.Errorf("Id = %q, want the name when id is empty", serverXML.Id)
+	}
+}
+
+// TestBuildServerXMLSchemaConstraints schema 中 startmode 枚举和 failure 正则约束的字段都会写入 XML
+func TestBuildServerXMLSchemaConstraints(t *testing.T) {
+	pattern := regexp.MustCompile(schemaPatterns["Server.SFailure"])
+	for _, mode := range schemaEnums["Server.SStartMode"] {
+		s := &Server{SName: "api", SExecutable: "api.exe", SStartMode: mode, SFailure: "restart:10 sec,none"}
+		if !pattern.MatchString(s.SFailure) {
+			t.Fatalf("failure %q does not match schema pattern", s.SFailure)
+		}
+		serverXML, err := s.BuildServerXML()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if serverXML.StartMode != mode || len(serverXML.OnFailures) != 2 {
+			t.Errorf("StartMode = %q, OnFailures = %d", serverXML.StartMode, len(serverXML.OnFailures))
+		}
+	}
+
+	for _, s := range []*Server{
+		{SName: "api", SExecutable: "api.exe", SStartMode: "Auto"},
+		{SName: "api", SExecutable: "api.exe", SFailure: "retry"},
+	} {
+		if pattern.MatchString(s.SFailure) && s.SFailure != "" {
+			t.Fatalf("failure %q matches schema pattern", s.SFailure)
+		}
+		if _, err := s.BuildServerXML(); err == nil {
+			t.Errorf("BuildServerXML(startmode=%q, failure=%q) succeeded, want error", s.SStartMode, s.SFailure)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package winserver
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gookit/goutil/fsutil"
+
+	"win_helper/pkg/util/fileutil"
+)
+
+// 升级状态
+const (
+	UpgradeStatusUpToDate = "up-to-date"
+	UpgradeStatusUpgraded = "upgraded"
+	UpgradeStatusPending  = "pending"
+	UpgradeStatusFailed   = "failed"
+)
+
+// UpgradeResult 记录单个服务包装程序的升级结果
+type UpgradeResult struct {
+	Executable string
+	XML        string
+	OldHash    string
+	NewHash    string
+	Status     string
+	Err        error
+}
+
+// UpgradeReport 汇总一次批量升级的全部结果
+type UpgradeReport struct {
+	Dir     string
+	DryRun  bool
+	Results []*UpgradeResult
+}
+
+// Count 返回指定状态的结果数量
+func (r *UpgradeReport) Count(status string) int {
+	n := 0
+	for _, result := range r.Results {
+		if result.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// String 以文本表格的形式输出升级报告
+func (r *UpgradeReport) String() string {
+	var b strings.Builder
+	for _, result := range r.Results {
+		rel, err := filepath.Rel(r.Dir, result.Executable)
+		if err != nil {
+			rel = result.Executable
+		}
+		line := fmt.Sprintf("%-10s %s (%s -> %s)", result.Status, rel, shortHash(result.OldHash), shortHash(result.NewHash))
+		if result.Err != nil {
+			line += fmt.Sprintf(": %v", result.Err)
+		}
+		b.WriteString(line + "\n")
+	}
+	fmt.Fprintf(&b, "total: %d, %s: %d, %s: %d, %s: %d, %s: %d\n",
+		len(r.Results),
+		UpgradeStatusUpToDate, r.Count(UpgradeStatusUpToDate),
+		UpgradeStatusUpgraded, r.Count(UpgradeStatusUpgraded),
+		UpgradeStatusPending, r.Count(UpgradeStatusPending),
+		UpgradeStatusFailed, r.Count(UpgradeStatusFailed),
+	)
+	return b.String()
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// FindWrappers 递归查找目录下所有带同名 WinSW XML 配置的服务包装程序，
+// 同名 XML 不是 WinSW 配置 (根元素不是 <service> 或缺少 <id> 和 <executable>) 的程序不会被替换
+func FindWrappers(dir string) ([]string, error) {
+	var wrappers []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(p), ".exe") {
+			return nil
+		}
+		if isWinSWConfig(wrapperXML(p)) {
+			wrappers = append(wrappers, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("扫描目录失败: %v", err)
+	}
+	return wrappers, nil
+}
+
+// isWinSWConfig 判断文件是否为 WinSW 服务配置
+func isWinSWConfig(filename string) bool {
+	if !fsutil.FileExist(filename) {
+		return false
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return false
+	}
+	serverXML, err := (&ServerXML{}).LoadXML(string(data))
+	if err != nil {
+		return false
+	}
+	return serverXML.Id != "" || serverXML.Executable != ""
+}
+
+// wrapperXML 返回包装程序对应的 XML 配置路径
+func wrapperXML(executable string) string {
+	return strings.TrimSuffix(executable, filepath.Ext(executable)) + ".xml"
+}
+
+// UpgradeWrappers 将目录下所有服务包装程序替换为内嵌的 WinSW，XML 配置保持不变。
+// dryRun 为 true 时只生成报告，不修改文件。
+func UpgradeWrappers(dir string, dryRun bool) (*UpgradeReport, error) {
+	wrappers, err := FindWrappers(dir)
+	if err != nil {
+		return nil, err
+	}
+	report := &UpgradeReport{Dir: dir, DryRun: dryRun}
+	newHash := hashBytes(WinSW)
+	for _, executable := range wrappers {
+		result := &UpgradeResult{
+			Executable: executable,
+			XML:        wrapperXML(executable),
+			NewHash:    newHash,
+		}
+		report.Results = append(report.Results, result)
+
+		data, err := os.ReadFile(executable)
+		if err != nil {
+			result.Status = UpgradeStatusFailed
+			result.Err = fmt.Errorf("读取服务文件失败: %v", err)
+			continue
+		}
+		result.OldHash = hashBytes(data)
+		if bytes.Equal(data, WinSW) {
+			result.Status = UpgradeStatusUpToDate
+			continue
+		}
+		if dryRun {
+			result.Status = UpgradeStatusPending
+			continue
+		}
+		if err := replaceFile(executable, WinSW); err != nil {
+			result.Status = UpgradeStatusFailed
+			result.Err = err
+			continue
+		}
+		result.Status = UpgradeStatusUpgraded
+	}
+	return report, nil
+}
+
+// replaceFile 先写入同目录下的临时文件，再重命名覆盖目标文件
+func replaceFile(filename string, data []byte) error {
+	if err := fileutil.WriteFileAtomic(filename, data, 0o644); err != nil {
+		return fmt.Errorf("替换服务文件失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package winserver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindWrappers(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"svc/svc.exe":       "old winsw",
+		"svc/svc.xml":       "<service><id>svc</id><executable>app.exe</executable></service>",
+		"exe/only.exe":      "wrapper",
+		"exe/only.xml":      "<service><executable>app.exe</executable></service>",
+		"app/app.exe":       "application",
+		"app/app.xml":       "<configuration><appSettings/></configuration>",
+		"empty/empty.exe":   "application",
+		"empty/empty.xml":   "<service></service>",
+		"broken/broken.exe": "application",
+		"broken/broken.xml": "<service><id>",
+		"plain/plain.exe":   "application",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wrappers, err := FindWrappers(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, w := range wrappers {
+		rel, _ := filepath.Rel(dir, w)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	want := []string{"exe/only.exe", "svc/svc.exe"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("FindWrappers() = %v, want %v", got, want)
+	}
+}
@@ -4,17 +4,20 @@ import (
 	"embed"
 	"fmt"
 	"io"
+	"path"
 )
 
 //go:embed templates/*
 var Templates embed.FS
 
 func GetTemplateByName(name string) ([]byte, error) {
-	file, err := Templates.Open(name)
+	file, err := Templates.Open(path.Join("templates", name))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open template file %s: %w", name, err)
 	}
 
+	defer file.Close()
+
 	// 读取文件内容
 	data, err := io.ReadAll(file)
 	if err != nil {
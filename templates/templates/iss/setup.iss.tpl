@@ -0,0 +1,55 @@
+; Script generated by win_helper.
+{% autoescape off %}
+#define MyAppName "{{ project.Name }}"
+#define MyAppVersion "{{ project.Version }}"
+#define MyAppPublisher "{{ project.Publisher }}"
+#define MyAppURL "{{ project.URL }}"
+{% if project.Exe %}#define MyAppExeName "{{ project.Exe }}"
+{% endif %}
+[Setup]
+AppId={{ appId }}
+AppName={% templatetag opencomment %}MyAppName}
+AppVersion={% templatetag opencomment %}MyAppVersion}
+AppPublisher={% templatetag opencomment %}MyAppPublisher}
+AppPublisherURL={% templatetag opencomment %}MyAppURL}
+AppSupportURL={% templatetag opencomment %}MyAppURL}
+AppUpdatesURL={% templatetag opencomment %}MyAppURL}
+DefaultDirName={{ project.DefaultDir }}
+DefaultGroupName={% templatetag opencomment %}MyAppName}
+OutputDir={{ project.OutputDir }}
+OutputBaseFilename={{ project.OutputBaseFilename }}
+Compression=lzma
+SolidCompression=yes
+WizardStyle=modern
+{% if services %}PrivilegesRequired=admin
+{% endif %}
+[Languages]
+Name: "english"; MessagesFile: "compiler:Default.isl"
+
+[Files]
+{% for file in files %}Source: "{{ file.Source }}"; DestDir: "{{ file.DestDir }}"; Flags: ignoreversion
+{% endfor %}
+[Icons]
+{% for icon in project.Icons %}Name: "{group}\{{ icon.Name }}"; Filename: "{{ icon.Filename }}"{% if icon.Parameters %}; Parameters: "{{ icon.Parameters }}"{% endif %}
+{% endfor %}Name: "{group}\{cm:UninstallProgram,{% templatetag opencomment %}MyAppName}}"; Filename: "{uninstallexe}"
+
+[Run]
+{% for service in services %}Filename: "{app}\{{ service.Executable }}"; Parameters: "install"; Flags: runhidden waituntilterminated; StatusMsg: "Installing service {{ service.Name }}"
+Filename: "{app}\{{ service.Executable }}"; Parameters: "start"; Flags: runhidden waituntilterminated; StatusMsg: "Starting service {{ service.Name }}"
+{% endfor %}{% for run in project.Run %}Filename: "{{ run.Filename }}"{% if run.Parameters %}; Parameters: "{{ run.Parameters }}"{% endif %}; Description: "{{ run.Description }}"; Flags: {{ run.Flags }}
+{% endfor %}{% if services %}
+[UninstallRun]
+{% for service in services %}Filename: "{app}\{{ service.Executable }}"; Parameters: "stop"; Flags: runhidden waituntilterminated; RunOnceId: "Stop{{ service.Id }}"
+Filename: "{app}\{{ service.Executable }}"; Parameters: "uninstall"; Flags: runhidden waituntilterminated; RunOnceId: "Uninstall{{ service.Id }}"
+{% endfor %}
+[Code]
+// 升级时先停止已安装的服务，避免服务程序被占用导致文件无法替换
+function PrepareToInstall(var NeedsRestart: Boolean): String;
+var
+  ResultCode: Integer;
+begin
+  Result := '';
+{% for service in services %}  if FileExists(ExpandConstant('{app}\{{ service.Executable }}')) then
+    Exec(ExpandConstant('{app}\{{ service.Executable }}'), 'stop', '', SW_HIDE, ewWaitUntilTerminated, ResultCode);
+{% endfor %}end;
+{% endif %}{% endautoescape %}
@@ -0,0 +1,12 @@
+{% autoescape off %}## [{{ changelog.Version }}]{% if changelog.Date %} - {{ changelog.Date }}{% endif %}
+{% if changelog.Breaking %}
+### ⚠ BREAKING CHANGES
+
+{% for entry in changelog.Breaking %}- {% if entry.Scope %}**{{ entry.Scope }}:** {% endif %}{{ entry.Description }} ({{ entry.ShortHash() }})
+{% endfor %}{% endif %}{% for section in changelog.Sections %}
+### {{ section.Title }}
+
+{% for entry in section.Entries %}- {% if entry.Scope %}**{{ entry.Scope }}:** {% endif %}{{ entry.Description }} ({{ entry.ShortHash() }})
+{% endfor %}{% endfor %}{% if not changelog.Breaking and not changelog.Sections %}
+No notable changes.
+{% endif %}{% endautoescape %}